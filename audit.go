@@ -0,0 +1,174 @@
+package grip
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// An AuditEntry is the record of a shutdown written by Audit as one JSON
+// object.
+type AuditEntry struct {
+	Signal     string         `json:"signal"`
+	Started    time.Time      `json:"started"`
+	Finished   time.Time      `json:"finished"`
+	DurationMS int64          `json:"duration_ms"`
+	Code       int            `json:"code"`
+	Handlers   []AuditHandler `json:"handlers"`
+}
+
+// An AuditHandler is the record of one ExitHandler within an AuditEntry.
+type AuditHandler struct {
+	Index      int       `json:"index"`
+	Name       string    `json:"name,omitempty"`
+	Started    time.Time `json:"started"`
+	DurationMS int64     `json:"duration_ms"`
+	// Status is "passed", "failed", "skipped", "running" for an ExitHandler
+	// still running when the exit code was sent, or "not started".
+	Status   string `json:"status"`
+	TimedOut bool   `json:"timed_out,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Audit produces a single self-contained record of a shutdown for audit logs:
+// which signal stopped the process, which ExitHandlers ran, which failed, when,
+// and how long it all took.
+//
+// It returns a SignalHandler recording the signal, to chain ahead of the Exit
+// family, a function wrapping the NamedExitHandlers to record, and a channel to
+// pass to the Exit family in place of ch. When an exit code is sent on it, an
+// AuditEntry for the sequence is written to w as one line of JSON and the code
+// is forwarded to ch:
+//
+//	record, wrap, codes := grip.Audit(auditLog, ch)
+//	grip.Trap(grip.Chain(record, grip.Exit(codes, os.Stderr, wrap(
+//		grip.NamedExitHandler{Name: "http", Fn: stopHTTP},
+//		grip.NamedExitHandler{Name: "db", Fn: closeDB},
+//	)...)), syscall.SIGINT, syscall.SIGTERM)
+//
+// The SignalHandler starts a new entry unless a sequence is already in
+// progress, so a repeating Trap writes one per sequence. Because the entry is
+// written when the exit code is sent, it also covers sequences that stop
+// early, such as ExitWithin running out of time, with the ExitHandlers that
+// were cut short or never started marked as such. The code recorded is the
+// exit code sent.
+func Audit(w io.Writer, ch chan int) (SignalHandler, func(fn ...NamedExitHandler) []ExitHandler, chan int) {
+	a := &auditor{w: orDiscard(w)}
+	codes := make(chan int)
+	go func() {
+		for code := range codes {
+			a.finish(code)
+			ch <- code
+		}
+	}()
+	record := func(s os.Signal) {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		if a.entry == nil {
+			a.begin(s)
+		}
+	}
+	return record, a.wrap, codes
+}
+
+// auditor collects an AuditEntry for each sequence.
+type auditor struct {
+	w io.Writer
+
+	mu    sync.Mutex
+	names []string
+	entry *AuditEntry
+}
+
+// begin starts a new entry for a sequence started by s, which may be nil. a.mu
+// must be held.
+func (a *auditor) begin(s os.Signal) {
+	a.entry = &AuditEntry{Started: time.Now(), Handlers: make([]AuditHandler, len(a.names))}
+	if s != nil {
+		a.entry.Signal = s.String()
+	}
+	for i, name := range a.names {
+		a.entry.Handlers[i] = AuditHandler{Index: i, Name: name, Status: "not started"}
+	}
+}
+
+// wrap wraps fn so their outcomes are recorded.
+func (a *auditor) wrap(fn ...NamedExitHandler) []ExitHandler {
+	a.mu.Lock()
+	a.names = make([]string, len(fn))
+	for i, n := range fn {
+		a.names[i] = n.Name
+	}
+	a.mu.Unlock()
+
+	handlers := make([]ExitHandler, len(fn))
+	for i, n := range fn {
+		i, n := i, n
+		handlers[i] = func() error {
+			start := time.Now()
+			a.mu.Lock()
+			if a.entry == nil {
+				a.begin(nil)
+			}
+			// An ExitHandler still running when its sequence ends must not
+			// record its outcome into the next sequence's entry.
+			entry := a.entry
+			entry.Handlers[i].Started = start
+			entry.Handlers[i].Status = "running"
+			a.mu.Unlock()
+
+			err := n.Fn()
+			a.done(entry, i, start, newResult(i, err))
+			return err
+		}
+	}
+	return handlers
+}
+
+// done records the result of the ExitHandler at index i in entry.
+func (a *auditor) done(entry *AuditEntry, i int, start time.Time, r HandlerResult) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if entry != a.entry {
+		// The entry was written while it was still running.
+		return
+	}
+	h := &entry.Handlers[i]
+	h.DurationMS = time.Since(start).Milliseconds()
+	h.Status = "passed"
+	h.TimedOut = r.TimedOut
+	switch {
+	case r.Skipped:
+		h.Status = "skipped"
+	case r.Failed():
+		h.Status = "failed"
+		h.Error = r.Err.Error()
+	}
+}
+
+// finish writes the current entry with code and clears it, so the next
+// sequence starts a new one.
+func (a *auditor) finish(code int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.entry == nil {
+		a.begin(nil)
+	}
+	entry := a.entry
+	a.entry = nil
+
+	entry.Finished = time.Now()
+	entry.DurationMS = entry.Finished.Sub(entry.Started).Milliseconds()
+	entry.Code = code
+	for i, h := range entry.Handlers {
+		if h.Status == "running" {
+			entry.Handlers[i].DurationMS = entry.Finished.Sub(h.Started).Milliseconds()
+		}
+	}
+	if b, err := json.Marshal(entry); err == nil {
+		fmt.Fprintf(a.w, "%s\n", b)
+	}
+}
@@ -0,0 +1,112 @@
+package grip_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/codycraven/grip"
+)
+
+// entries decodes the AuditEntries written to buf.
+func entries(t *testing.T, buf *bytes.Buffer) []grip.AuditEntry {
+	t.Helper()
+	var out []grip.AuditEntry
+	s := bufio.NewScanner(buf)
+	for s.Scan() {
+		var e grip.AuditEntry
+		if err := json.Unmarshal(s.Bytes(), &e); err != nil {
+			t.Fatalf("line %q is not an AuditEntry: %v", s.Text(), err)
+		}
+		out = append(out, e)
+	}
+	return out
+}
+
+func statuses(e grip.AuditEntry) []string {
+	out := make([]string, len(e.Handlers))
+	for i, h := range e.Handlers {
+		out[i] = h.Status
+	}
+	return out
+}
+
+func TestAudit(t *testing.T) {
+	var buf bytes.Buffer
+	ch := make(chan int, 1)
+	record, wrap, codes := grip.Audit(&buf, ch)
+	h := grip.Chain(record, grip.Exit(codes, nil, wrap(
+		grip.NamedExitHandler{Name: "http", Fn: pass},
+		grip.NamedExitHandler{Name: "db", Fn: fail},
+	)...))
+
+	// Each sequence of a repeating Trap writes its own entry.
+	for _, sig := range []os.Signal{syscall.SIGTERM, syscall.SIGINT} {
+		h(sig)
+		if code := <-ch; code != 2 {
+			t.Errorf("exit code %d, want 2", code)
+		}
+	}
+	got := entries(t, &buf)
+	if len(got) != 2 {
+		t.Fatalf("%d entries, want 2", len(got))
+	}
+	for i, sig := range []string{"terminated", "interrupt"} {
+		e := got[i]
+		if e.Signal != sig || e.Code != 2 || len(e.Handlers) != 2 {
+			t.Errorf("entry %d = %+v, want signal %q, code 2 and 2 handlers", i, e, sig)
+			continue
+		}
+		if e.Handlers[0].Name != "http" || e.Handlers[0].Status != "passed" {
+			t.Errorf("entry %d handler 0 = %+v, want http passed", i, e.Handlers[0])
+		}
+		if h := e.Handlers[1]; h.Name != "db" || h.Index != 1 || h.Status != "failed" || h.Error != "failed" {
+			t.Errorf("entry %d handler 1 = %+v, want db failed", i, h)
+		}
+		if e.Finished.Before(e.Started) {
+			t.Errorf("entry %d finished before it started", i)
+		}
+	}
+}
+
+func TestAuditCutShort(t *testing.T) {
+	var buf bytes.Buffer
+	ch := make(chan int, 1)
+	release := make(chan struct{})
+	defer close(release)
+	record, wrap, codes := grip.Audit(&buf, ch)
+	h := grip.Chain(record, grip.ExitWithin(50*time.Millisecond, codes, nil, wrap(
+		grip.NamedExitHandler{Name: "stall", Fn: func() error {
+			<-release
+			return nil
+		}},
+		grip.NamedExitHandler{Name: "never", Fn: pass},
+	)...))
+
+	h(syscall.SIGTERM)
+	code := <-ch
+	got := entries(t, &buf)
+	if len(got) != 1 {
+		t.Fatalf("%d entries, want 1", len(got))
+	}
+	if got[0].Code != code {
+		t.Errorf("recorded code %d, want the code sent, %d", got[0].Code, code)
+	}
+	if s := statuses(got[0]); s[0] != "running" || s[1] != "not started" {
+		t.Errorf("statuses %q, want [running, not started]", s)
+	}
+
+	// The stalled ExitHandler returning later does not leak into the next
+	// sequence's entry.
+	release <- struct{}{}
+	h(syscall.SIGINT)
+	<-ch
+	got = entries(t, &buf)
+	if len(got) != 1 || got[0].Signal != "interrupt" {
+		t.Fatalf("entries %+v, want the second sequence", got)
+	}
+}
@@ -0,0 +1,59 @@
+package grip
+
+import "time"
+
+// A Clock schedules the timers grip uses to end a stuck shutdown, such as
+// Deadline.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// AfterFunc calls f in its own goroutine once d has elapsed.
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// A Timer is a pending call scheduled by a Clock.
+type Timer interface {
+	// Stop prevents the call from happening and reports whether it did so,
+	// false meaning the call already happened or was already stopped.
+	Stop() bool
+}
+
+// DefaultClock is the Clock used by this package. It defaults to the system
+// clock and can be replaced, for example with a fake clock that tests advance
+// by hand.
+var DefaultClock Clock = systemClock{}
+
+// systemClock is a Clock backed by package time.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
+
+// Deadline calls ExitFunc with code once d has elapsed on DefaultClock, a last
+// resort against a graceful shutdown that hangs. Calling the returned cancel
+// function before then prevents the exit entirely; it reports whether it did.
+//
+//	grip.Trap(func(s os.Signal) {
+//		cancel := grip.Deadline(30*time.Second, 124)
+//		defer cancel()
+//		grip.Exit(ch, os.Stderr, stopHTTP, closeDB)(s)
+//	}, syscall.SIGINT, syscall.SIGTERM)
+func Deadline(d time.Duration, code int) (cancel func() bool) {
+	return DefaultClock.AfterFunc(d, func() {
+		ExitFunc(code)
+	}).Stop
+}
+
+// sleep waits for d to elapse on DefaultClock.
+func sleep(d time.Duration) {
+	done := make(chan struct{})
+	DefaultClock.AfterFunc(d, func() {
+		close(done)
+	})
+	<-done
+}
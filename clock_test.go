@@ -0,0 +1,48 @@
+package grip_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/codycraven/grip"
+	"github.com/codycraven/grip/griptest"
+)
+
+func TestDeadline(t *testing.T) {
+	clock := griptest.NewFakeClock(t)
+	exits := griptest.CaptureExit(t)
+	grip.Deadline(30*time.Second, 124)
+	clock.Advance(29 * time.Second)
+	select {
+	case code := <-exits:
+		t.Fatalf("exited with %d before the deadline", code)
+	default:
+	}
+	clock.Advance(time.Second)
+	select {
+	case code := <-exits:
+		if code != 124 {
+			t.Errorf("exit code %d, want 124", code)
+		}
+	default:
+		t.Fatal("did not exit at the deadline")
+	}
+}
+
+func TestDeadlineCancel(t *testing.T) {
+	clock := griptest.NewFakeClock(t)
+	exits := griptest.CaptureExit(t)
+	cancel := grip.Deadline(30*time.Second, 124)
+	if !cancel() {
+		t.Error("cancel did not report preventing the exit")
+	}
+	clock.Advance(time.Minute)
+	select {
+	case code := <-exits:
+		t.Errorf("exited with %d after cancel", code)
+	default:
+	}
+	if cancel() {
+		t.Error("second cancel reported preventing the exit")
+	}
+}
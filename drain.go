@@ -0,0 +1,81 @@
+package grip
+
+import (
+	"context"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Drain creates a SignalHandler for the complete drain sequence of a server
+// behind a load balancer. On the first signal it:
+//
+//  1. sets health to false, so the health endpoint starts failing;
+//  2. waits grace, measured on DefaultClock, for the load balancer to notice
+//     and stop sending traffic;
+//  3. runs the ExitHandlers in order, writing failures to os.Stderr;
+//  4. calls ExitFunc with the exit code described by Exit.
+//
+// For example:
+//
+//	var healthy atomic.Bool
+//	healthy.Store(true)
+//	http.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+//		if !healthy.Load() {
+//			w.WriteHeader(http.StatusServiceUnavailable)
+//		}
+//	})
+//	grip.Trap(grip.Drain(&healthy, 10*time.Second, grip.Shutdownable(ctx, server), closeDB),
+//		syscall.SIGINT, syscall.SIGTERM)
+//
+// A second signal during the grace period cuts it short and moves straight on
+// to the ExitHandlers. That is either another delivery of the signal that
+// started the drain, which Drain watches for itself, or another call to the
+// SignalHandler. The sequence runs once; later calls are ignored.
+func Drain(health *atomic.Bool, grace time.Duration, fn ...ExitHandler) SignalHandler {
+	var (
+		mu      sync.Mutex
+		started bool
+		cut     chan struct{}
+	)
+	return func(s os.Signal) {
+		mu.Lock()
+		if started {
+			if cut != nil {
+				close(cut)
+				cut = nil
+			}
+			mu.Unlock()
+			return
+		}
+		started = true
+		cut = make(chan struct{})
+		interrupted := cut
+		mu.Unlock()
+
+		health.Store(false)
+		ctx, release := context.WithCancel(context.Background())
+		defer release()
+		stop := release
+		if s != nil {
+			ctx, stop = notifyContext(ctx, s)
+		}
+		done := make(chan struct{})
+		timer := DefaultClock.AfterFunc(grace, func() {
+			close(done)
+		})
+		select {
+		case <-done:
+		case <-interrupted:
+		case <-ctx.Done():
+		}
+		timer.Stop()
+		stop()
+		mu.Lock()
+		cut = nil
+		mu.Unlock()
+
+		ExitFunc(exitCode(os.Stderr, fn))
+	}
+}
@@ -0,0 +1,87 @@
+package grip_test
+
+import (
+	"errors"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/codycraven/grip"
+	"github.com/codycraven/grip/griptest"
+)
+
+func TestDrain(t *testing.T) {
+	clock := griptest.NewFakeClock(t)
+	exits := griptest.CaptureExit(t)
+	var healthy, ran atomic.Bool
+	healthy.Store(true)
+	h := grip.Drain(&healthy, 10*time.Second, pass, func() error {
+		ran.Store(true)
+		return errors.New("failed")
+	})
+	go h(syscall.SIGTERM)
+	waitPending(t, clock, 1)
+	if healthy.Load() {
+		t.Error("health still passing during the grace period")
+	}
+
+	clock.Advance(9 * time.Second)
+	select {
+	case code := <-exits:
+		t.Fatalf("exit with %d before the grace period ended", code)
+	case <-time.After(20 * time.Millisecond):
+	}
+	if ran.Load() {
+		t.Fatal("ExitHandlers ran during the grace period")
+	}
+	clock.Advance(time.Second)
+	select {
+	case code := <-exits:
+		if code != 2 {
+			t.Errorf("exit code %d, want 2", code)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no exit after the grace period")
+	}
+	if !ran.Load() {
+		t.Error("ExitHandlers did not run")
+	}
+
+	// The sequence runs once.
+	h(syscall.SIGTERM)
+	select {
+	case code := <-exits:
+		t.Errorf("second drain exited with %d", code)
+	default:
+	}
+}
+
+func TestDrainInterrupted(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		second func(h grip.SignalHandler)
+	}{
+		{"another call", func(h grip.SignalHandler) { h(syscall.SIGINT) }},
+		{"another delivery", func(grip.SignalHandler) { griptest.Send(syscall.SIGTERM) }},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := griptest.NewFakeClock(t)
+			exits := griptest.CaptureExit(t)
+			var healthy atomic.Bool
+			h := grip.Drain(&healthy, time.Minute, pass)
+			go h(syscall.SIGTERM)
+			waitPending(t, clock, 1)
+			griptest.WaitRegistered(t, syscall.SIGTERM)
+			tt.second(h)
+			select {
+			case code := <-exits:
+				if code != 0 {
+					t.Errorf("exit code %d, want 0", code)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("second signal did not cut the grace period short")
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package grip
+
+// An Encoder turns the results of a shutdown sequence into an exit code.
+type Encoder interface {
+	Encode(results []HandlerResult) int
+}
+
+// An EncoderFunc is a function used as an Encoder. Any reducer accepted by
+// ExitWith, such as Bitmask, can be converted to one.
+type EncoderFunc func(results []HandlerResult) int
+
+// Encode calls f.
+func (f EncoderFunc) Encode(results []HandlerResult) int {
+	return f(results)
+}
+
+// BitmaskEncoder encodes results as described by Exit: every failed
+// ExitHandler adds 1<<Index. It is the default Encoder.
+type BitmaskEncoder struct{}
+
+// Encode returns the Bitmask of results.
+func (BitmaskEncoder) Encode(results []HandlerResult) int {
+	return Bitmask(results)
+}
+
+// CountEncoder encodes results as the number of failed ExitHandlers.
+type CountEncoder struct{}
+
+// Encode returns the number of failed results.
+func (CountEncoder) Encode(results []HandlerResult) int {
+	n := 0
+	for _, r := range results {
+		if r.Failed() {
+			n++
+		}
+	}
+	return n
+}
+
+// FirstFailureEncoder encodes results as the position of the first failed
+// ExitHandler counting from 1, or 0 if none failed.
+type FirstFailureEncoder struct{}
+
+// Encode returns Index+1 of the first failed result, or 0.
+func (FirstFailureEncoder) Encode(results []HandlerResult) int {
+	first := -1
+	for _, r := range results {
+		if r.Failed() && (first < 0 || r.Index < first) {
+			first = r.Index
+		}
+	}
+	return first + 1
+}
+
+// BinaryEncoder encodes results as 1 if any ExitHandler failed and 0
+// otherwise.
+type BinaryEncoder struct{}
+
+// Encode returns 1 if any result failed, or 0.
+func (BinaryEncoder) Encode(results []HandlerResult) int {
+	for _, r := range results {
+		if r.Failed() {
+			return 1
+		}
+	}
+	return 0
+}
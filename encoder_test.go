@@ -0,0 +1,56 @@
+package grip_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/codycraven/grip"
+)
+
+func TestEncoders(t *testing.T) {
+	errFailed := errors.New("failed")
+	results := []grip.HandlerResult{
+		{Index: 0},
+		{Index: 1, Err: errFailed},
+		{Index: 2, Skipped: true},
+		{Index: 3, Err: errFailed},
+	}
+	tests := []struct {
+		name    string
+		encoder grip.Encoder
+		want    int
+		clean   int
+	}{
+		{"BitmaskEncoder", grip.BitmaskEncoder{}, 10, 0},
+		{"CountEncoder", grip.CountEncoder{}, 2, 0},
+		{"FirstFailureEncoder", grip.FirstFailureEncoder{}, 2, 0},
+		{"BinaryEncoder", grip.BinaryEncoder{}, 1, 0},
+		{"EncoderFunc", grip.EncoderFunc(grip.Bitmask), 10, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.encoder.Encode(results); got != tt.want {
+				t.Errorf("Encode = %d, want %d", got, tt.want)
+			}
+			if got := tt.encoder.Encode(results[:1]); got != tt.clean {
+				t.Errorf("Encode of a clean shutdown = %d, want %d", got, tt.clean)
+			}
+		})
+	}
+}
+
+func TestWithEncoder(t *testing.T) {
+	run := grip.New(
+		grip.WithEncoder(grip.CountEncoder{}),
+		grip.WithErrorWriter(nil),
+		grip.WithExitHandlers(fail, pass, fail),
+	).Runner()
+	code, err := run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code != 2 {
+		t.Errorf("exit code %d, want 2", code)
+	}
+}
@@ -0,0 +1,61 @@
+package grip
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FromEnv returns the Options configured by environment variables, so
+// operators can tune shutdown without recompiling:
+//
+//	GRIP_SIGNALS           comma-separated signal names, see ParseSignal (WithSignals)
+//	GRIP_SHUTDOWN_TIMEOUT  a time.ParseDuration duration (WithTimeout)
+//	GRIP_CONCURRENT        a strconv.ParseBool boolean (WithConcurrent)
+//
+// Unset or empty variables are ignored. The Options go after the program's
+// own so the environment takes precedence:
+//
+//	env, err := grip.FromEnv()
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	grip.New(append([]grip.Option{grip.WithExitHandlers(stopHTTP, closeDB)}, env...)...).Wait()
+//
+// FromEnv returns an error naming the variable if any value is malformed.
+func FromEnv() ([]Option, error) {
+	var opts []Option
+	if v := os.Getenv("GRIP_SIGNALS"); v != "" {
+		var signals []os.Signal
+		for _, name := range strings.Split(v, ",") {
+			s, err := ParseSignal(name)
+			if err != nil {
+				return nil, fmt.Errorf("GRIP_SIGNALS: %w", err)
+			}
+			signals = append(signals, s)
+		}
+		opts = append(opts, WithSignals(signals...))
+	}
+	if v := os.Getenv("GRIP_SHUTDOWN_TIMEOUT"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("GRIP_SHUTDOWN_TIMEOUT: %w", err)
+		}
+		if d <= 0 {
+			return nil, fmt.Errorf("GRIP_SHUTDOWN_TIMEOUT: %s is not positive", d)
+		}
+		opts = append(opts, WithTimeout(d))
+	}
+	if v := os.Getenv("GRIP_CONCURRENT"); v != "" {
+		concurrent, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("GRIP_CONCURRENT: %w", err)
+		}
+		if concurrent {
+			opts = append(opts, WithConcurrent())
+		}
+	}
+	return opts, nil
+}
@@ -0,0 +1,59 @@
+package grip_test
+
+import (
+	"os"
+	"slices"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/codycraven/grip"
+)
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("GRIP_SIGNALS", "term, sigINT")
+	t.Setenv("GRIP_SHUTDOWN_TIMEOUT", "15s")
+	t.Setenv("GRIP_CONCURRENT", "true")
+	opts, err := grip.FromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := grip.New(opts...)
+	if got, want := h.Signals(), []os.Signal{syscall.SIGTERM, syscall.SIGINT}; !slices.Equal(got, want) {
+		t.Errorf("signals %v, want %v", got, want)
+	}
+	if got := h.Timeout(); got != 15*time.Second {
+		t.Errorf("timeout %v, want 15s", got)
+	}
+}
+
+func TestFromEnvUnset(t *testing.T) {
+	for _, name := range []string{"GRIP_SIGNALS", "GRIP_SHUTDOWN_TIMEOUT", "GRIP_CONCURRENT"} {
+		t.Setenv(name, "")
+	}
+	opts, err := grip.FromEnv()
+	if err != nil || len(opts) != 0 {
+		t.Errorf("FromEnv() = %d options, %v, want none", len(opts), err)
+	}
+}
+
+func TestFromEnvInvalid(t *testing.T) {
+	tests := []struct {
+		name, value string
+	}{
+		{"GRIP_SIGNALS", "SIGTERM,SIGBOGUS"},
+		{"GRIP_SHUTDOWN_TIMEOUT", "soon"},
+		{"GRIP_SHUTDOWN_TIMEOUT", "-1s"},
+		{"GRIP_CONCURRENT", "sometimes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name+"="+tt.value, func(t *testing.T) {
+			t.Setenv(tt.name, tt.value)
+			_, err := grip.FromEnv()
+			if err == nil || !strings.HasPrefix(err.Error(), tt.name+": ") {
+				t.Errorf("FromEnv() error %v, want one naming %s", err, tt.name)
+			}
+		})
+	}
+}
@@ -0,0 +1,503 @@
+package grip
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// shutting is set once shutdown begins.
+var shutting atomic.Bool
+
+// Shutting reports whether shutdown is in progress: it returns true once any
+// of the Exit family of functions has started running ExitHandlers, and stays
+// true from then on. It lets request handlers reject new work while the
+// process drains:
+//
+//	func middleware(next http.Handler) http.Handler {
+//		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+//			if grip.Shutting() {
+//				http.Error(w, "shutting down", http.StatusServiceUnavailable)
+//				return
+//			}
+//			next.ServeHTTP(w, r)
+//		})
+//	}
+//
+// Shutting is safe to call from any goroutine.
+func Shutting() bool {
+	return shutting.Load()
+}
+
+// ErrTimeout is wrapped by the errors of ExitHandlers that did not finish in
+// time.
+var ErrTimeout = errors.New("timed out")
+
+// ErrSkipped is returned by an ExitHandler that chose not to run, such as one
+// created by IfOK. A skipped ExitHandler is reported with Skipped set and does
+// not fail.
+var ErrSkipped = errors.New("skipped")
+
+// ExitSignalAware creates a SignalHandler that passes exit codes to a channel
+// like Exit, but reports which signal triggered a clean shutdown.
+//
+// When every ExitHandler passes, the exit code follows the shell convention of
+// 128 plus the signal number (130 for SIGINT, 143 for SIGTERM). When any
+// ExitHandler fails, the bitmask described by Exit takes precedence and the
+// signal number is not included. With fewer than 8 ExitHandlers a code of 128
+// or above therefore always means a clean shutdown, while a code below 128
+// identifies the ExitHandler(s) that failed.
+//
+// Signals that do not carry a number (anything other than a syscall.Signal)
+// produce 0 on a clean shutdown, matching Exit.
+//
+//	ch := make(chan int)
+//	grip.Trap(grip.ExitSignalAware(ch, os.Stderr, closeDB), syscall.SIGINT, syscall.SIGTERM)
+//	os.Exit(<-ch)
+func ExitSignalAware(ch chan int, errWriter io.Writer, fn ...ExitHandler) SignalHandler {
+	return exclusive(WriterSink(errWriter), func(s os.Signal) {
+		exit := exitCode(errWriter, fn)
+		if exit == 0 {
+			if n, ok := signum(s); ok {
+				exit = 128 + n
+			}
+		}
+		ch <- exit
+	})
+}
+
+// A HandlerResult describes the outcome of a single ExitHandler.
+type HandlerResult struct {
+	// Index is the position of the ExitHandler in the list it was provided in.
+	Index int
+	// Err is the error returned by the ExitHandler, nil if it passed or was
+	// skipped.
+	Err error
+	// Skipped is set if the ExitHandler returned ErrSkipped.
+	Skipped bool
+	// TimedOut is set if the ExitHandler failed because it ran out of time,
+	// that is its error wraps ErrTimeout or context.DeadlineExceeded. It is
+	// only informational: a timed out ExitHandler sets its bit like any other
+	// failure, since the exit code cannot tell the two apart.
+	TimedOut bool
+}
+
+// newResult creates the HandlerResult of the ExitHandler at index i returning
+// err.
+func newResult(i int, err error) HandlerResult {
+	if errors.Is(err, ErrSkipped) {
+		return HandlerResult{Index: i, Skipped: true}
+	}
+	return HandlerResult{
+		Index:    i,
+		Err:      err,
+		TimedOut: errors.Is(err, ErrTimeout) || errors.Is(err, context.DeadlineExceeded),
+	}
+}
+
+// Failed reports whether the ExitHandler failed.
+func (r HandlerResult) Failed() bool {
+	return r.Err != nil
+}
+
+// Bitmask reduces HandlerResults to the exit code described by Exit, adding
+// 1<<Index for every failed ExitHandler.
+func Bitmask(results []HandlerResult) int {
+	exit := 0
+	for _, r := range results {
+		if r.Failed() {
+			exit |= 1 << r.Index
+		}
+	}
+	return exit
+}
+
+// ExitFallback is like Exit but never blocks on ch. If nothing is ready to
+// receive the exit code when the ExitHandlers have finished, and ch has no
+// free buffer space, Exit would hang the shutdown forever; ExitFallback
+// instead writes the exit code to errWriter and passes it to ExitFunc itself:
+//
+//	grip.Trap(grip.ExitFallback(ch, os.Stderr, closeDB), syscall.SIGTERM)
+//
+// A receiver must therefore already be waiting on ch, as main usually is with
+// os.Exit(<-ch), or ch must be buffered. A nil ch always takes the fallback.
+func ExitFallback(ch chan int, errWriter io.Writer, fn ...ExitHandler) SignalHandler {
+	sink := WriterSink(errWriter)
+	return exclusive(sink, func(_ os.Signal) {
+		code := exitCode(errWriter, fn)
+		select {
+		case ch <- code:
+		default:
+			sink.Note(fmt.Sprintf("no receiver for exit code %d, exiting", code))
+			ExitFunc(code)
+		}
+	})
+}
+
+// ExitWith creates a SignalHandler that calls each ExitHandler in order and
+// passes the exit code computed by reduce to a channel.
+//
+// Each failed ExitHandler is written to errWriter. Passing Bitmask as reduce
+// produces the same exit codes as Exit, while a custom reducer can collapse
+// failures into a single code or map specific ExitHandlers to specific codes:
+//
+//	anyFailed := func(results []grip.HandlerResult) int {
+//		for _, r := range results {
+//			if r.Failed() {
+//				return 1
+//			}
+//		}
+//		return 0
+//	}
+//	grip.Trap(grip.ExitWith(anyFailed, ch, os.Stderr, closeDB, flushLogs), syscall.SIGTERM)
+func ExitWith(reduce func(results []HandlerResult) int, ch chan int, errWriter io.Writer, fn ...ExitHandler) SignalHandler {
+	sink := WriterSink(errWriter)
+	return exclusive(sink, func(_ os.Signal) {
+		ch <- reduce(runExitHandlers(fn, indexReporter(sink)))
+	})
+}
+
+// ExitConcurrentN is like Exit but runs up to n ExitHandlers at a time, or all
+// of them at once if n is less than 1.
+//
+// Each ExitHandler keeps the bit of its position in fn, 1<<i, and the exit code
+// is the OR of the bits of every failed ExitHandler, so it does not depend on
+// the order in which they finish. Failures are written to errWriter in that
+// same position order once every ExitHandler has returned.
+//
+//	grip.Trap(grip.ExitConcurrentN(2, ch, os.Stderr, closeDB, closeCache, flushLogs), syscall.SIGTERM)
+func ExitConcurrentN(n int, ch chan int, errWriter io.Writer, fn ...ExitHandler) SignalHandler {
+	if n < 1 || n > len(fn) {
+		n = len(fn)
+	}
+	return exclusive(WriterSink(errWriter), func(_ os.Signal) {
+		report := bitReporter(errWriter)
+		results := runConcurrent(n, fn)
+		for _, r := range results {
+			if r.Failed() {
+				report(r)
+			}
+		}
+		ch <- Bitmask(results)
+	})
+}
+
+// runConcurrent calls up to n ExitHandlers at a time and returns their results
+// once all of them have returned.
+func runConcurrent(n int, fn []ExitHandler) []HandlerResult {
+	shutting.Store(true)
+	results := make([]HandlerResult, len(fn))
+	sem := make(chan struct{}, n)
+	var wg sync.WaitGroup
+	wg.Add(len(fn))
+	for i, f := range fn {
+		sem <- struct{}{}
+		go func(i int, f ExitHandler) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			results[i] = newResult(i, f())
+		}(i, f)
+	}
+	wg.Wait()
+	return results
+}
+
+// An AsyncExitHandler starts a cleanup step and calls done with its result once
+// it completes, possibly from another goroutine. Only the first call to done is
+// used.
+type AsyncExitHandler func(done func(error))
+
+// Async adapts an AsyncExitHandler to an ExitHandler that waits up to timeout
+// for done to be called. If done has not been called in time the ExitHandler
+// fails with an error wrapping ErrTimeout.
+func Async(timeout time.Duration, fn AsyncExitHandler) ExitHandler {
+	return func() error {
+		result := make(chan error, 1)
+		fn(func(err error) {
+			select {
+			case result <- err:
+			default:
+			}
+		})
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		select {
+		case err := <-result:
+			return err
+		case <-timer.C:
+			return fmt.Errorf("done not called within %s: %w", timeout, ErrTimeout)
+		}
+	}
+}
+
+// ExitAsync is like Exit for AsyncExitHandlers. The AsyncExitHandlers run
+// sequentially: each one is started only after the previous one has called
+// done or timed out, and each gets its own timeout.
+//
+//	grip.Trap(grip.ExitAsync(5*time.Second, ch, os.Stderr,
+//		func(done func(error)) { client.Deregister(done) },
+//	), syscall.SIGTERM)
+func ExitAsync(timeout time.Duration, ch chan int, errWriter io.Writer, fn ...AsyncExitHandler) SignalHandler {
+	handlers := make([]ExitHandler, len(fn))
+	for i, f := range fn {
+		handlers[i] = Async(timeout, f)
+	}
+	return Exit(ch, errWriter, handlers...)
+}
+
+// A CtxExitHandler is an ExitHandler that receives a context, which is
+// cancelled when it should abandon its work.
+type CtxExitHandler func(ctx context.Context) error
+
+// signalKey is the context key under which ExitCtx and ExitByContext store the
+// signal that started the sequence.
+type signalKey struct{}
+
+// SignalFrom returns the signal that started the sequence a CtxExitHandler's
+// context belongs to, and false if it was not started by a signal, for
+// example when the SignalHandler was called with nil.
+func SignalFrom(ctx context.Context) (os.Signal, bool) {
+	s, ok := ctx.Value(signalKey{}).(os.Signal)
+	return s, ok && s != nil
+}
+
+// ExitCtx is like Exit for CtxExitHandlers. They share a context that is
+// cancelled if a second signal arrives while they are still running, so
+// cleanup that respects it aborts quickly and the process moves on to exit:
+//
+//	grip.Trap(grip.ExitCtx(ch, os.Stderr,
+//		func(ctx context.Context) error { return server.Shutdown(ctx) },
+//		func(ctx context.Context) error { return queue.Flush(ctx) },
+//	), syscall.SIGINT, syscall.SIGTERM)
+//
+// A second signal is either another delivery of the signal that started the
+// sequence, which ExitCtx watches for itself, or another call to the
+// SignalHandler, for example from TrapRepeat or a second Trap. Once cancelled
+// the context stays cancelled, so ExitHandlers that have not started yet see
+// it already done.
+//
+// A CtxExitHandler with a timeout of its own, such as one derived with
+// context.WithTimeout, is bounded by whichever of the two fires first.
+func ExitCtx(ch chan int, errWriter io.Writer, fn ...CtxExitHandler) SignalHandler {
+	var (
+		mu     sync.Mutex
+		cancel context.CancelFunc
+	)
+	return func(s os.Signal) {
+		mu.Lock()
+		if cancel != nil {
+			cancel()
+			mu.Unlock()
+			return
+		}
+		ctx, release := context.WithCancel(context.WithValue(context.Background(), signalKey{}, s))
+		defer release()
+		stop := release
+		if s != nil {
+			ctx, stop = notifyContext(ctx, s)
+		}
+		cancel = stop
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			cancel = nil
+			mu.Unlock()
+			stop()
+		}()
+
+		handlers := make([]ExitHandler, len(fn))
+		for i, f := range fn {
+			f := f
+			handlers[i] = func() error {
+				return f(ctx)
+			}
+		}
+		ch <- exitCode(errWriter, handlers)
+	}
+}
+
+// ExitByContext is like ExitWithin with ctx as the shutdown budget, typically a
+// context whose deadline comes from an orchestrator. Each CtxExitHandler
+// receives ctx, so its deadline applies to every step, and once ctx is done no
+// further CtxExitHandlers are started:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), terminationGracePeriod)
+//	defer cancel()
+//	grip.Trap(grip.ExitByContext(ctx, ch, os.Stderr,
+//		func(ctx context.Context) error { return server.Shutdown(ctx) },
+//		func(ctx context.Context) error { return db.Close() },
+//	), syscall.SIGTERM)
+//
+// The running CtxExitHandler and every one that was not started count as
+// failed with an error wrapping ctx's error, context.DeadlineExceeded when the
+// deadline passed. The exit code is sent without waiting for the running
+// CtxExitHandler to return.
+func ExitByContext(ctx context.Context, ch chan int, errWriter io.Writer, fn ...CtxExitHandler) SignalHandler {
+	return exclusive(WriterSink(errWriter), func(s os.Signal) {
+		shutting.Store(true)
+		ctx := context.WithValue(ctx, signalKey{}, s)
+		report := bitReporter(errWriter)
+		results := make([]HandlerResult, len(fn))
+		i := 0
+	run:
+		for ; i < len(fn) && ctx.Err() == nil; i++ {
+			done := make(chan error, 1)
+			go func(f CtxExitHandler) {
+				done <- f(ctx)
+			}(fn[i])
+			select {
+			case err := <-done:
+				results[i] = newResult(i, err)
+				if results[i].Failed() {
+					report(results[i])
+				}
+			case <-ctx.Done():
+				break run
+			}
+		}
+		for ; i < len(fn); i++ {
+			results[i] = newResult(i, fmt.Errorf("shutdown context done: %w", ctx.Err()))
+			report(results[i])
+		}
+		ch <- Bitmask(results)
+	})
+}
+
+// indexReporter returns a function that reports a failed HandlerResult to sink
+// with no bit, for exit codes that are not a bitmask.
+func indexReporter(sink Sink) func(HandlerResult) {
+	return func(r HandlerResult) {
+		sink.HandlerFailed(r.Index, 0, r.Err)
+	}
+}
+
+// exclusive wraps fn so that a call made while a previous call is still
+// running is reported to sink and dropped.
+func exclusive(sink Sink, fn SignalHandler) SignalHandler {
+	return exclusiveOn(new(atomic.Bool), sink, fn)
+}
+
+// exclusiveOn is exclusive with the running state held in running, so it can
+// be shared with other entry points.
+func exclusiveOn(running *atomic.Bool, sink Sink, fn SignalHandler) SignalHandler {
+	return func(s os.Signal) {
+		if !running.CompareAndSwap(false, true) {
+			sink.Note(fmt.Sprintf("ignored %s: shutdown already in progress", s))
+			return
+		}
+		defer running.Store(false)
+		fn(s)
+	}
+}
+
+// runExitHandlers calls each ExitHandler in order and returns their results.
+// failed is called as soon as an ExitHandler fails.
+func runExitHandlers(fn []ExitHandler, failed func(HandlerResult)) []HandlerResult {
+	shutting.Store(true)
+	results := make([]HandlerResult, len(fn))
+	for i, f := range fn {
+		results[i] = newResult(i, f())
+		if results[i].Failed() {
+			failed(results[i])
+		}
+	}
+	return results
+}
+
+// ExitWithin is like Exit but caps the total time spent running all of the
+// ExitHandlers.
+//
+// If the sequence is still running once total has elapsed, no further
+// ExitHandlers are started and the exit code is sent immediately, with the
+// running ExitHandler and every ExitHandler that was not started counted as
+// failed. Their errors wrap ErrTimeout. The ExitHandler that was running when
+// time ran out is not stopped and may still be running after the exit code has
+// been sent.
+//
+//	grip.Trap(grip.ExitWithin(10*time.Second, ch, os.Stderr, stopHTTP, closeDB), syscall.SIGTERM)
+func ExitWithin(total time.Duration, ch chan int, errWriter io.Writer, fn ...ExitHandler) SignalHandler {
+	return exclusive(WriterSink(errWriter), func(_ os.Signal) {
+		ch <- Bitmask(runWithin(total, fn, bitReporter(errWriter)))
+	})
+}
+
+// runWithin calls each ExitHandler in order until total has elapsed and
+// returns their results as described by ExitWithin. failed is called as soon
+// as an ExitHandler fails.
+func runWithin(total time.Duration, fn []ExitHandler, failed func(HandlerResult)) []HandlerResult {
+	shutting.Store(true)
+	timer := time.NewTimer(total)
+	defer timer.Stop()
+	results := make([]HandlerResult, len(fn))
+	i := 0
+run:
+	for ; i < len(fn); i++ {
+		done := make(chan error, 1)
+		go func(f ExitHandler) {
+			done <- f()
+		}(fn[i])
+		select {
+		case err := <-done:
+			results[i] = newResult(i, err)
+			if results[i].Failed() {
+				failed(results[i])
+			}
+		case <-timer.C:
+			break run
+		}
+	}
+	for ; i < len(fn); i++ {
+		results[i] = newResult(i, fmt.Errorf("shutdown exceeded %s: %w", total, ErrTimeout))
+		failed(results[i])
+	}
+	return results
+}
+
+// Stages calls groups of ExitHandlers and returns the bitmask exit code. Each
+// stage's ExitHandlers run concurrently and the next stage only starts once
+// every ExitHandler in the current one has returned:
+//
+//	ch := make(chan int)
+//	grip.Trap(func(_ os.Signal) {
+//		ch <- grip.Stages(os.Stderr,
+//			[]grip.ExitHandler{stopAccepting},
+//			[]grip.ExitHandler{drainDB, drainCache},
+//			[]grip.ExitHandler{flushLogs},
+//		)
+//	}, syscall.SIGINT, syscall.SIGTERM)
+//
+// Bits are assigned as if the stages were flattened into a single list, in the
+// order provided: above, stopAccepting is 1, drainDB 2, drainCache 4 and
+// flushLogs 8. Failures are written to errWriter in that same order once their
+// stage has finished.
+func Stages(errWriter io.Writer, stages ...[]ExitHandler) (code int) {
+	shutting.Store(true)
+	report := bitReporter(errWriter)
+	var results []HandlerResult
+	for _, stage := range stages {
+		offset := len(results)
+		results = append(results, make([]HandlerResult, len(stage))...)
+		var wg sync.WaitGroup
+		wg.Add(len(stage))
+		for i, f := range stage {
+			go func(i int, f ExitHandler) {
+				defer wg.Done()
+				results[i] = newResult(i, f())
+			}(offset+i, f)
+		}
+		wg.Wait()
+		for _, r := range results[offset:] {
+			if r.Failed() {
+				report(r)
+			}
+		}
+	}
+	return Bitmask(results)
+}
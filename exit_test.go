@@ -0,0 +1,460 @@
+package grip_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/rand"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/codycraven/grip"
+	"github.com/codycraven/grip/griptest"
+)
+
+func pass() error { return nil }
+
+func fail() error { return errors.New("failed") }
+
+// syncBuffer is a bytes.Buffer safe for concurrent use, for output written
+// from the trap goroutine.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestExitSignalAware(t *testing.T) {
+	tests := []struct {
+		name string
+		sig  os.Signal
+		fn   []grip.ExitHandler
+		want int
+	}{
+		{"clean SIGINT", syscall.SIGINT, []grip.ExitHandler{pass}, 130},
+		{"clean SIGTERM", syscall.SIGTERM, []grip.ExitHandler{pass, pass}, 143},
+		{"failure takes precedence", syscall.SIGTERM, []grip.ExitHandler{pass, fail}, 2},
+		{"no signal number", nil, []grip.ExitHandler{pass}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ch := make(chan int, 1)
+			grip.ExitSignalAware(ch, nil, tt.fn...)(tt.sig)
+			if got := <-ch; got != tt.want {
+				t.Errorf("exit code %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExitWith(t *testing.T) {
+	anyFailed := func(results []grip.HandlerResult) int {
+		for _, r := range results {
+			if r.Failed() {
+				return 1
+			}
+		}
+		return 0
+	}
+	tests := []struct {
+		name string
+		fn   []grip.ExitHandler
+		want int
+	}{
+		{"all pass", []grip.ExitHandler{pass, pass}, 0},
+		{"one fails", []grip.ExitHandler{pass, fail, fail}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			ch := make(chan int, 1)
+			grip.ExitWith(anyFailed, ch, &buf, tt.fn...)(syscall.SIGTERM)
+			if got := <-ch; got != tt.want {
+				t.Errorf("exit code %d, want %d", got, tt.want)
+			}
+			if tt.want != 0 && !strings.Contains(buf.String(), "exit handler 1 failed: failed") {
+				t.Errorf("output %q does not report the failed ExitHandler by index", buf.String())
+			}
+		})
+	}
+}
+
+func TestExitWithBitmask(t *testing.T) {
+	ch := make(chan int, 1)
+	grip.ExitWith(grip.Bitmask, ch, nil, fail, pass, fail)(syscall.SIGTERM)
+	if got := <-ch; got != 5 {
+		t.Errorf("exit code %d, want 5", got)
+	}
+}
+
+// sleeper returns an ExitHandler that sleeps for d.
+func sleeper(d time.Duration) grip.ExitHandler {
+	return func() error {
+		time.Sleep(d)
+		return nil
+	}
+}
+
+func TestExitWithin(t *testing.T) {
+	var buf bytes.Buffer
+	ch := make(chan int, 1)
+	start := time.Now()
+	grip.ExitWithin(60*time.Millisecond, ch, &buf,
+		sleeper(40*time.Millisecond),
+		sleeper(40*time.Millisecond),
+		sleeper(40*time.Millisecond),
+	)(syscall.SIGTERM)
+	// The first ExitHandler finishes in time, the second is running when time
+	// runs out and the third is never started.
+	if got := <-ch; got != 6 {
+		t.Errorf("exit code %d, want 6", got)
+	}
+	if elapsed := time.Since(start); elapsed >= 120*time.Millisecond {
+		t.Errorf("took %s, want less than the 120ms the ExitHandlers sleep", elapsed)
+	}
+	if !strings.Contains(buf.String(), "timed out") {
+		t.Errorf("output %q does not report a timeout", buf.String())
+	}
+}
+
+func TestExitWithinInTime(t *testing.T) {
+	ch := make(chan int, 1)
+	grip.ExitWithin(time.Second, ch, nil, sleeper(time.Millisecond), pass)(syscall.SIGTERM)
+	if got := <-ch; got != 0 {
+		t.Errorf("exit code %d, want 0", got)
+	}
+}
+
+func TestStages(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		order []string
+	)
+	step := func(name string, err error) grip.ExitHandler {
+		return func() error {
+			mu.Lock()
+			order = append(order, name)
+			mu.Unlock()
+			return err
+		}
+	}
+	var buf bytes.Buffer
+	code := grip.Stages(&buf,
+		[]grip.ExitHandler{step("stop", nil)},
+		[]grip.ExitHandler{step("db", errors.New("db")), step("cache", nil)},
+		[]grip.ExitHandler{step("logs", errors.New("logs"))},
+	)
+	if code != 2|8 {
+		t.Errorf("exit code %d, want 10", code)
+	}
+	if len(order) != 4 || order[0] != "stop" || order[3] != "logs" {
+		t.Errorf("ran in order %v, want stop first and logs last", order)
+	}
+	if want := "added 2 to exit code for error: db\nadded 8 to exit code for error: logs\n"; buf.String() != want {
+		t.Errorf("output %q, want %q", buf.String(), want)
+	}
+}
+
+func TestShutting(t *testing.T) {
+	ch := make(chan int, 1)
+	var during bool
+	grip.Exit(ch, nil, func() error {
+		during = grip.Shutting()
+		return nil
+	})(syscall.SIGTERM)
+	<-ch
+	if !during {
+		t.Error("Shutting() = false while ExitHandlers run")
+	}
+	if !grip.Shutting() {
+		t.Error("Shutting() = false after shutdown")
+	}
+}
+
+func TestExitReentrant(t *testing.T) {
+	var (
+		runs    atomic.Int32
+		started = make(chan struct{})
+		release = make(chan struct{})
+	)
+	var buf syncBuffer
+	ch := make(chan int, 2)
+	h := grip.Exit(ch, &buf, func() error {
+		if runs.Add(1) == 1 {
+			close(started)
+		}
+		<-release
+		return nil
+	})
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			defer wg.Done()
+			h(syscall.SIGTERM)
+		}()
+	}
+	<-started
+	// The call that lost the race returns without running the ExitHandler.
+	deadline := time.Now().Add(time.Second)
+	for !strings.Contains(buf.String(), "shutdown already in progress") {
+		if time.Now().After(deadline) {
+			t.Fatalf("second call was not dropped, output %q", buf.String())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+
+	if n := runs.Load(); n != 1 {
+		t.Errorf("ExitHandler ran %d times, want 1", n)
+	}
+	if len(ch) != 1 {
+		t.Errorf("%d exit codes sent, want 1", len(ch))
+	}
+}
+
+func TestShutdownerRunReentrant(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	sd := grip.NewShutdowner()
+	sd.Register("slow", func() error {
+		close(started)
+		<-release
+		return nil
+	})
+	done := make(chan error)
+	go func() {
+		_, err := sd.Run(nil)
+		done <- err
+	}()
+	<-started
+	if _, err := sd.Run(nil); !errors.Is(err, grip.ErrShutdownInProgress) {
+		t.Errorf("concurrent Run returned %v, want ErrShutdownInProgress", err)
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Errorf("first Run returned %v", err)
+	}
+}
+
+func TestExitConcurrentNStress(t *testing.T) {
+	const handlers = 16
+	var want int
+	fn := make([]grip.ExitHandler, handlers)
+	for i := range fn {
+		failing := i%3 == 0
+		if failing {
+			want |= 1 << i
+		}
+		fn[i] = func() error {
+			time.Sleep(time.Duration(rand.Intn(2000)) * time.Microsecond)
+			if failing {
+				return errors.New("failed")
+			}
+			return nil
+		}
+	}
+	for _, n := range []int{1, 3, handlers} {
+		for run := 0; run < 20; run++ {
+			ch := make(chan int, 1)
+			grip.ExitConcurrentN(n, ch, nil, fn...)(syscall.SIGTERM)
+			if got := <-ch; got != want {
+				t.Fatalf("n=%d run %d: exit code %b, want %b", n, run, got, want)
+			}
+		}
+	}
+}
+
+func TestExitConcurrentNBound(t *testing.T) {
+	var running, peak atomic.Int32
+	fn := make([]grip.ExitHandler, 10)
+	for i := range fn {
+		fn[i] = func() error {
+			n := running.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			running.Add(-1)
+			return nil
+		}
+	}
+	ch := make(chan int, 1)
+	grip.ExitConcurrentN(3, ch, nil, fn...)(syscall.SIGTERM)
+	<-ch
+	if p := peak.Load(); p > 3 {
+		t.Errorf("%d ExitHandlers ran at once, want at most 3", p)
+	}
+}
+
+func TestExitAsync(t *testing.T) {
+	ch := make(chan int, 1)
+	grip.ExitAsync(20*time.Millisecond, ch, nil,
+		func(done func(error)) { go done(nil) },
+		func(done func(error)) {
+			go func() {
+				done(errors.New("deregister failed"))
+				done(nil) // only the first call counts
+			}()
+		},
+		func(done func(error)) {}, // never calls done
+	)(syscall.SIGTERM)
+	if got := <-ch; got != 6 {
+		t.Errorf("exit code %d, want 6", got)
+	}
+}
+
+func TestAsyncTimeout(t *testing.T) {
+	err := grip.Async(time.Millisecond, func(func(error)) {})()
+	if !errors.Is(err, grip.ErrTimeout) {
+		t.Errorf("Async returned %v, want ErrTimeout", err)
+	}
+}
+
+func TestExitCtxSecondSignal(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		second func(h grip.SignalHandler)
+	}{
+		{"another call", func(h grip.SignalHandler) { h(syscall.SIGINT) }},
+		{"another delivery", func(grip.SignalHandler) { griptest.Send(syscall.SIGTERM) }},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf syncBuffer
+			ch := make(chan int, 1)
+			started := make(chan struct{})
+			var later error
+			h := grip.ExitCtx(ch, &buf,
+				func(ctx context.Context) error {
+					close(started)
+					<-ctx.Done()
+					return ctx.Err()
+				},
+				func(ctx context.Context) error {
+					later = ctx.Err()
+					return nil
+				},
+			)
+			go h(syscall.SIGTERM)
+			<-started
+			griptest.WaitRegistered(t, syscall.SIGTERM)
+			tt.second(h)
+			select {
+			case code := <-ch:
+				if code != 1 {
+					t.Errorf("exit code %d, want 1", code)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("second signal did not cancel the context")
+			}
+			if !errors.Is(later, context.Canceled) {
+				t.Errorf("later CtxExitHandler saw %v, want the context already cancelled", later)
+			}
+		})
+	}
+}
+
+func TestExitFallback(t *testing.T) {
+	tests := []struct {
+		name string
+		ch   chan int
+	}{
+		{"unbuffered without receiver", make(chan int)},
+		{"full buffer", func() chan int {
+			ch := make(chan int, 1)
+			ch <- 9
+			return ch
+		}()},
+		{"nil channel", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exits := griptest.CaptureExit(t)
+			var buf bytes.Buffer
+			done := make(chan struct{})
+			go func() {
+				grip.ExitFallback(tt.ch, &buf, pass, fail)(syscall.SIGTERM)
+				close(done)
+			}()
+			select {
+			case <-done:
+			case <-time.After(time.Second):
+				t.Fatal("ExitFallback blocked without a receiver")
+			}
+			if code := <-exits; code != 2 {
+				t.Errorf("exit code %d, want 2", code)
+			}
+			if !strings.Contains(buf.String(), "no receiver for exit code 2, exiting") {
+				t.Errorf("output %q does not note the fallback", buf.String())
+			}
+		})
+	}
+}
+
+func TestExitFallbackReceiver(t *testing.T) {
+	exits := griptest.CaptureExit(t)
+	ch := make(chan int, 1)
+	grip.ExitFallback(ch, nil, pass)(syscall.SIGTERM)
+	if code := <-ch; code != 0 {
+		t.Errorf("exit code %d, want 0", code)
+	}
+	select {
+	case code := <-exits:
+		t.Errorf("fallback exit with %d despite a receiver", code)
+	default:
+	}
+}
+
+func TestExitByContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	var buf bytes.Buffer
+	ch := make(chan int, 1)
+	release := make(chan struct{})
+	defer close(release)
+	var seen context.Context
+	grip.ExitByContext(ctx, ch, &buf,
+		func(ctx context.Context) error {
+			seen = ctx
+			return nil
+		},
+		func(context.Context) error {
+			<-release
+			return nil
+		},
+		func(context.Context) error {
+			t.Error("CtxExitHandler started after the context expired")
+			return nil
+		},
+	)(syscall.SIGTERM)
+	if code := <-ch; code != 6 {
+		t.Errorf("exit code %d, want 6 for the running and the unstarted CtxExitHandler", code)
+	}
+	if s, ok := grip.SignalFrom(seen); !ok || s != syscall.SIGTERM {
+		t.Errorf("SignalFrom() = %v, %t, want SIGTERM", s, ok)
+	}
+	if n := strings.Count(buf.String(), "shutdown context done: context deadline exceeded"); n != 2 {
+		t.Errorf("output %q reports %d expired CtxExitHandlers, want 2", buf.String(), n)
+	}
+}
@@ -0,0 +1,59 @@
+package grip
+
+import (
+	"strconv"
+	"strings"
+)
+
+// An ExitError carries the exit code of a shutdown sequence together with the
+// errors of the ExitHandlers that failed. The error-returning variants of the
+// Exit family, such as Run, return one when any ExitHandler fails:
+//
+//	if err := grip.Run(closeDB, flushLogs); err != nil {
+//		var exitErr *grip.ExitError
+//		if errors.As(err, &exitErr) {
+//			os.Exit(exitErr.Code)
+//		}
+//	}
+//
+// errors.Is and errors.As also match the errors of the individual ExitHandlers.
+type ExitError struct {
+	// Code is the exit code described by Exit.
+	Code int
+	// Errs are the errors of the failed ExitHandlers, in order.
+	Errs []error
+}
+
+func (e *ExitError) Error() string {
+	msgs := make([]string, len(e.Errs))
+	for i, err := range e.Errs {
+		msgs[i] = err.Error()
+	}
+	return "exit code " + strconv.Itoa(e.Code) + ": " + strings.Join(msgs, "; ")
+}
+
+// Unwrap returns Errs.
+func (e *ExitError) Unwrap() []error {
+	return e.Errs
+}
+
+// Run calls each ExitHandler in order and returns an *ExitError if any of
+// them failed, or nil otherwise.
+func Run(fn ...ExitHandler) error {
+	return exitError(runExitHandlers(fn, func(HandlerResult) {}))
+}
+
+// exitError returns an *ExitError for the failed results, or nil if none
+// failed.
+func exitError(results []HandlerResult) error {
+	var errs []error
+	for _, r := range results {
+		if r.Failed() {
+			errs = append(errs, r.Err)
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return &ExitError{Code: Bitmask(results), Errs: errs}
+}
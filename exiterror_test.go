@@ -0,0 +1,35 @@
+package grip_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/codycraven/grip"
+)
+
+func TestRunExitError(t *testing.T) {
+	errDB := errors.New("db")
+	err := grip.Run(pass, func() error { return errDB }, fail)
+	var exitErr *grip.ExitError
+	if !errors.As(err, &exitErr) {
+		t.Fatalf("Run returned %v, want an *ExitError", err)
+	}
+	if exitErr.Code != 6 {
+		t.Errorf("Code = %d, want 6", exitErr.Code)
+	}
+	if len(exitErr.Errs) != 2 {
+		t.Errorf("Errs = %v, want two errors", exitErr.Errs)
+	}
+	if !errors.Is(err, errDB) {
+		t.Error("errors.Is does not match an ExitHandler's error")
+	}
+	if want := "exit code 6: db; failed"; err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestRunClean(t *testing.T) {
+	if err := grip.Run(pass, pass); err != nil {
+		t.Errorf("Run returned %v", err)
+	}
+}
@@ -0,0 +1,623 @@
+package grip
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CancelAll creates an ExitHandler that calls each context.CancelFunc in the
+// order provided. Cancelling a context cannot fail, so the ExitHandler always
+// returns nil.
+//
+// CancelAll is typically placed first in the ExitHandler list so subsystems
+// watching those contexts begin winding down before later ExitHandlers close
+// the resources they depend on.
+//
+//	grip.Exit(ch, os.Stderr,
+//		grip.CancelAll(cancelWorkers, cancelPoller),
+//		closeDB,
+//	)
+func CancelAll(cancels ...context.CancelFunc) ExitHandler {
+	return func() error {
+		for _, cancel := range cancels {
+			cancel()
+		}
+		return nil
+	}
+}
+
+// Command creates an ExitHandler that runs a command, such as deregistering
+// from service discovery, and fails if the command does not exit successfully.
+//
+// The command's combined output is included in the returned error so it is
+// written to the error writer alongside the exit code. Cancelling ctx, for
+// example with a shutdown deadline, kills a command that has hung.
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+//	defer cancel()
+//	grip.Exit(ch, os.Stderr, grip.Command(ctx, "consul", "services", "deregister", "-id=web"))
+func Command(ctx context.Context, name string, args ...string) ExitHandler {
+	return func() error {
+		out, err := exec.CommandContext(ctx, name, args...).CombinedOutput()
+		if err != nil {
+			if out = bytes.TrimSpace(out); len(out) > 0 {
+				return fmt.Errorf("%s: %w: %s", name, err, out)
+			}
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		return nil
+	}
+}
+
+// WaitFor creates an ExitHandler that calls poll every interval until it
+// reports true, useful for draining work before closing resources:
+//
+//	grip.Exit(ch, os.Stderr,
+//		grip.WaitFor(func() (bool, error) { return queue.Len() == 0, nil }, 100*time.Millisecond, 30*time.Second),
+//		closeQueue,
+//	)
+//
+// poll is called immediately and the ExitHandler fails if poll returns an
+// error or has not reported true within timeout, in which case the error
+// wraps ErrTimeout. It also fails, without calling poll, if interval is not
+// positive.
+func WaitFor(poll func() (bool, error), interval, timeout time.Duration) ExitHandler {
+	return func() error {
+		if interval <= 0 {
+			return fmt.Errorf("poll interval %s is not positive", interval)
+		}
+		deadline := time.NewTimer(timeout)
+		defer deadline.Stop()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			ok, err := poll()
+			if err != nil {
+				return err
+			}
+			if ok {
+				return nil
+			}
+			select {
+			case <-ticker.C:
+			case <-deadline.C:
+				return fmt.Errorf("condition not met after %s: %w", timeout, ErrTimeout)
+			}
+		}
+	}
+}
+
+// Shutdownable creates an ExitHandler from anything with a
+// Shutdown(context.Context) error method, such as *http.Server, calling it with
+// ctx.
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+//	defer cancel()
+//	grip.Exit(ch, os.Stderr,
+//		grip.Shutdownable(ctx, httpServer),
+//		grip.Shutdownable(ctx, grpcGateway),
+//	)
+func Shutdownable(ctx context.Context, s interface{ Shutdown(context.Context) error }) ExitHandler {
+	return func() error {
+		return s.Shutdown(ctx)
+	}
+}
+
+// DrainChan creates an ExitHandler that passes the items remaining in ch to
+// consume, so work already queued in a pipeline is handled before downstream
+// resources are closed.
+//
+// The ExitHandler returns nil once ch is closed or empty. It fails with an
+// error wrapping ErrTimeout if items are still being drained after timeout.
+//
+//	grip.Exit(ch, os.Stderr,
+//		grip.DrainChan(jobs, process, 5*time.Second),
+//		closeDB,
+//	)
+func DrainChan[T any](ch <-chan T, consume func(T), timeout time.Duration) ExitHandler {
+	return func() error {
+		deadline := time.NewTimer(timeout)
+		defer deadline.Stop()
+		for {
+			select {
+			case <-deadline.C:
+				return fmt.Errorf("channel not drained after %s: %w", timeout, ErrTimeout)
+			default:
+			}
+			select {
+			case v, ok := <-ch:
+				if !ok {
+					return nil
+				}
+				consume(v)
+			default:
+				return nil
+			}
+		}
+	}
+}
+
+// Emit creates an ExitHandler that sends a final event, such as a "shutting
+// down" metric or heartbeat, by calling fn with a context that expires after
+// timeout. A slow telemetry endpoint therefore cannot hang shutdown.
+//
+// The ExitHandler fails with fn's error, or with an error wrapping ErrTimeout
+// if fn has not returned within timeout. fn should return promptly once its
+// context is done; if it does not, it is left running in the background.
+//
+//	grip.Exit(ch, os.Stderr,
+//		grip.Emit(func(ctx context.Context) error {
+//			return metrics.Event(ctx, "shutdown")
+//		}, 2*time.Second),
+//	)
+func Emit(fn func(ctx context.Context) error, timeout time.Duration) ExitHandler {
+	return func() error {
+		return runWithTimeout(fn, timeout)
+	}
+}
+
+// Upload creates an ExitHandler for a final upload, such as flushing logs or
+// artifacts to object storage before a batch job exits. fn receives a context
+// that expires after timeout.
+//
+// Unlike Emit, Upload does not leave fn running once timeout has elapsed: it
+// waits for fn to return, so an upload cut short can abort cleanly instead of
+// leaving a partial object behind when the process exits. fn must therefore
+// return once its context is done. The ExitHandler fails with fn's error,
+// wrapping ErrTimeout as well if it failed after the timeout.
+//
+//	grip.Exit(ch, os.Stderr, grip.Upload(func(ctx context.Context) error {
+//		return bucket.Put(ctx, "logs/run.txt", logs)
+//	}, time.Minute))
+func Upload(fn func(ctx context.Context) error, timeout time.Duration) ExitHandler {
+	return func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		err := fn(ctx)
+		if err != nil && ctx.Err() != nil {
+			return fmt.Errorf("not finished after %s: %w: %w", timeout, ErrTimeout, err)
+		}
+		return err
+	}
+}
+
+// UploadRetry is like Upload but calls fn up to attempts times while it
+// fails, waiting backoff between attempts, to ride out transient failures.
+// timeout bounds all of the attempts together, not each of them. The
+// ExitHandler fails with the last attempt's error, which also wraps
+// ErrTimeout once timeout has elapsed.
+func UploadRetry(fn func(ctx context.Context) error, timeout time.Duration, attempts int, backoff time.Duration) ExitHandler {
+	return Upload(func(ctx context.Context) error {
+		var err error
+		for i := 0; i < max(attempts, 1); i++ {
+			if i > 0 {
+				timer := time.NewTimer(backoff)
+				select {
+				case <-timer.C:
+				case <-ctx.Done():
+					timer.Stop()
+					return err
+				}
+			}
+			if err = fn(ctx); err == nil {
+				return nil
+			}
+		}
+		return err
+	}, timeout)
+}
+
+// runWithTimeout calls fn with a context that expires after timeout and
+// returns its error, or an error wrapping ErrTimeout if fn has not returned
+// by then.
+func runWithTimeout(fn func(ctx context.Context) error, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	result := make(chan error, 1)
+	go func() {
+		result <- fn(ctx)
+	}()
+	select {
+	case err := <-result:
+		if errors.Is(err, context.DeadlineExceeded) {
+			return fmt.Errorf("%w: %w", ErrTimeout, err)
+		}
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("not finished after %s: %w", timeout, ErrTimeout)
+	}
+}
+
+// Capture creates an ExitHandler that calls fn and stores its error in err,
+// for use with IfOK.
+func Capture(err *error, fn ExitHandler) ExitHandler {
+	return func() error {
+		*err = fn()
+		return *err
+	}
+}
+
+// IfOK creates an ExitHandler that only calls fn if *prev is nil, and returns
+// ErrSkipped otherwise. Combined with Capture it makes a step conditional on an
+// earlier step in the same sequence:
+//
+//	var flushErr error
+//	grip.Exit(ch, os.Stderr,
+//		stopHTTP,
+//		grip.Capture(&flushErr, flushQueue),
+//		grip.IfOK(&flushErr, deleteQueue), // only once the queue is flushed
+//		closeDB,                           // always
+//	)
+//
+// A skipped ExitHandler leaves its bit unset, exactly as if it had passed. Only
+// its HandlerResult, which has Skipped set, tells the two apart.
+func IfOK(prev *error, fn ExitHandler) ExitHandler {
+	return func() error {
+		if *prev != nil {
+			return fmt.Errorf("previous step failed: %w", ErrSkipped)
+		}
+		return fn()
+	}
+}
+
+// ForSignal creates a CtxExitHandler that only calls fn when the sequence it
+// is part of was started by sig, and returns ErrSkipped otherwise. It lets a
+// single sequence serve several signals, such as a full shutdown on SIGTERM
+// and a reload on SIGHUP:
+//
+//	grip.TrapRepeat(grip.ExitCtx(ch, os.Stderr,
+//		grip.ForSignal(syscall.SIGHUP, reloadConfig),
+//		grip.ForSignal(syscall.SIGTERM, stopHTTP),
+//		func(context.Context) error { return flushLogs() }, // always
+//	), syscall.SIGHUP, syscall.SIGTERM)
+//
+// The triggering signal is read from the context with SignalFrom, so ForSignal
+// works with ExitCtx and ExitByContext. A Handler restricts its ExitHandlers
+// with NamedExitHandler.Signal instead.
+func ForSignal(sig os.Signal, fn ExitHandler) CtxExitHandler {
+	return func(ctx context.Context) error {
+		s, _ := SignalFrom(ctx)
+		return onlyFor(sig, s, fn)()
+	}
+}
+
+// onlyFor returns fn if s is sig, and otherwise an ExitHandler that skips it.
+func onlyFor(sig, s os.Signal, fn ExitHandler) ExitHandler {
+	if s != sig {
+		return func() error {
+			return fmt.Errorf("not triggered by %s: %w", sig, ErrSkipped)
+		}
+	}
+	return fn
+}
+
+// Timeout creates an ExitHandler that fails with an error wrapping ErrTimeout
+// if fn has not returned within d. fn is not stopped and may keep running in
+// the background after the ExitHandler has returned.
+//
+// The HandlerResult of a timed out ExitHandler has TimedOut set, which tells a
+// slow step apart from a failing one in reports, but both set the same bit.
+func Timeout(d time.Duration, fn ExitHandler) ExitHandler {
+	return func() error {
+		return runWithTimeout(func(context.Context) error {
+			return fn()
+		}, d)
+	}
+}
+
+// AwaitWaitGroup creates an ExitHandler that waits for wg, so "wait for the
+// workers" can be placed at a precise point in the sequence. It fails with an
+// error wrapping ErrTimeout if wg has not finished within timeout.
+//
+//	grip.Exit(ch, os.Stderr,
+//		grip.CancelAll(cancelWorkers),
+//		grip.AwaitWaitGroup(&workers, 10*time.Second),
+//		closeDB,
+//	)
+//
+// Since a WaitGroup cannot be cancelled, a goroutine is left waiting on wg
+// after a timeout until it finishes.
+func AwaitWaitGroup(wg *sync.WaitGroup, timeout time.Duration) ExitHandler {
+	return func() error {
+		done := make(chan struct{})
+		go func() {
+			wg.Wait()
+			close(done)
+		}()
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		select {
+		case <-done:
+			return nil
+		case <-timer.C:
+			return fmt.Errorf("wait group not done after %s: %w", timeout, ErrTimeout)
+		}
+	}
+}
+
+// DrainCounter creates an ExitHandler that checks active every poll until it
+// drops to zero, for work tracked with a counter rather than a WaitGroup, such
+// as in-flight requests of a custom router:
+//
+//	var active atomic.Int64
+//	// in the request handler: active.Add(1); defer active.Add(-1)
+//	grip.Exit(ch, os.Stderr,
+//		stopAccepting,
+//		grip.DrainCounter(&active, 50*time.Millisecond, 30*time.Second),
+//		closeDB,
+//	)
+//
+// The ExitHandler fails with an error wrapping ErrTimeout if active is still
+// above zero after timeout.
+func DrainCounter(active *atomic.Int64, poll, timeout time.Duration) ExitHandler {
+	wait := WaitFor(func() (bool, error) { return active.Load() <= 0, nil }, poll, timeout)
+	return func() error {
+		err := wait()
+		if errors.Is(err, ErrTimeout) {
+			return fmt.Errorf("%d still active after %s: %w", active.Load(), timeout, ErrTimeout)
+		}
+		return err
+	}
+}
+
+// AwaitFileGone creates an ExitHandler that checks every poll until the file
+// at path no longer exists, such as a lock file held by another process that
+// must finish first:
+//
+//	grip.Exit(ch, os.Stderr, grip.AwaitFileGone("/run/app/migrate.lock", 100*time.Millisecond, time.Minute), closeDB)
+//
+// A file that is already absent passes immediately. The ExitHandler fails with
+// an error wrapping ErrTimeout if the file still exists after timeout, or with
+// the error of checking for it if that fails for another reason.
+func AwaitFileGone(path string, poll, timeout time.Duration) ExitHandler {
+	wait := WaitFor(func() (bool, error) {
+		_, err := os.Stat(path)
+		if errors.Is(err, os.ErrNotExist) {
+			return true, nil
+		}
+		return false, err
+	}, poll, timeout)
+	return func() error {
+		err := wait()
+		if errors.Is(err, ErrTimeout) {
+			return fmt.Errorf("%s still exists after %s: %w", path, timeout, ErrTimeout)
+		}
+		return err
+	}
+}
+
+// CloseAll creates one ExitHandler per io.Closer, each closing it, so every
+// resource gets its own bit in the exit code:
+//
+//	grip.Exit(ch, os.Stderr, grip.CloseAll(db, cache, logFile)...)
+//
+// Prefer CloseAll when it matters which resource failed to close, and
+// MultiClose when the resources form one logical step.
+func CloseAll(cs ...io.Closer) []ExitHandler {
+	fn := make([]ExitHandler, len(cs))
+	for i, c := range cs {
+		fn[i] = c.Close
+	}
+	return fn
+}
+
+// MultiClose creates a single ExitHandler that closes every io.Closer in order,
+// continuing past failures, and returns their errors joined with errors.Join.
+// All of the resources share one bit in the exit code; see CloseAll to give
+// each its own.
+//
+//	grip.Exit(ch, os.Stderr, stopHTTP, grip.MultiClose(conns...))
+func MultiClose(cs ...io.Closer) ExitHandler {
+	return func() error {
+		var errs []error
+		for _, c := range cs {
+			if err := c.Close(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	}
+}
+
+// Notify creates an ExitHandler that writes payload to conn, for telling a
+// supervisor or process manager that shutdown has begun:
+//
+//	conn, _ := net.Dial("unix", "/run/supervisor.sock")
+//	grip.Exit(ch, os.Stderr, grip.Notify(conn, []byte("stopping\n"), time.Second), stopHTTP)
+//
+// If conn has a SetWriteDeadline(time.Time) error method, as net.Conn does,
+// the write is given timeout to complete. A failed write fails the ExitHandler
+// like any other, so it sets its bit while the remaining ExitHandlers still
+// run.
+func Notify(conn io.Writer, payload []byte, timeout time.Duration) ExitHandler {
+	return func() error {
+		if d, ok := conn.(interface{ SetWriteDeadline(time.Time) error }); ok {
+			if err := d.SetWriteDeadline(time.Now().Add(timeout)); err != nil {
+				return err
+			}
+		}
+		n, err := conn.Write(payload)
+		if err == nil && n < len(payload) {
+			err = io.ErrShortWrite
+		}
+		return err
+	}
+}
+
+// DrainPool creates an ExitHandler that shuts down a pool of worker
+// goroutines reading from jobs. It closes jobs so no new work is fed to the
+// pool, then waits for each of the workers to report on done that it has
+// finished its current work and returned:
+//
+//	jobs := make(chan struct{})
+//	done := make(chan struct{})
+//	for i := 0; i < 8; i++ {
+//		go func() {
+//			defer func() { done <- struct{}{} }()
+//			for range jobs {
+//				work()
+//			}
+//		}()
+//	}
+//	grip.Exit(ch, os.Stderr, grip.DrainPool(jobs, 8, done, 30*time.Second), closeDB)
+//
+// Closing done also counts as every worker having finished. The ExitHandler
+// fails with an error wrapping ErrTimeout if the workers have not all finished
+// within timeout. jobs is closed only the first time the ExitHandler is
+// called, so calling it again is safe: it waits for the workers that had not
+// finished yet. Since it closes jobs, nothing else may close or send on jobs
+// once it has run.
+func DrainPool(jobs chan<- struct{}, workers int, done <-chan struct{}, timeout time.Duration) ExitHandler {
+	var (
+		once     sync.Once
+		mu       sync.Mutex
+		finished int
+	)
+	return func() error {
+		once.Do(func() {
+			close(jobs)
+		})
+		mu.Lock()
+		defer mu.Unlock()
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		for ; finished < workers; finished++ {
+			select {
+			case _, ok := <-done:
+				if !ok {
+					finished = workers
+					return nil
+				}
+			case <-timer.C:
+				return fmt.Errorf("%d of %d workers still running after %s: %w", workers-finished, workers, timeout, ErrTimeout)
+			}
+		}
+		return nil
+	}
+}
+
+// ReopenFile creates an ExitHandler that reopens the file at path and swaps it
+// into *target under mu, closing the previous file. It implements
+// logrotate-style reopening, where the log file is renamed away and the
+// process must start writing a fresh one at the original path:
+//
+//	var (
+//		logMu   sync.Mutex
+//		logFile *os.File
+//	)
+//	reopen := grip.ReopenFile("/var/log/app.log", &logFile, &logMu)
+//	grip.TrapRepeat(func(_ os.Signal) { reopen() }, syscall.SIGHUP)
+//
+// Writers must hold mu while using *target. The file is opened for appending
+// and created if needed before the previous one is closed, so *target is left
+// untouched if opening fails.
+func ReopenFile(path string, target **os.File, mu *sync.Mutex) ExitHandler {
+	return func() error {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o666)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		prev := *target
+		*target = f
+		mu.Unlock()
+		if prev != nil {
+			return prev.Close()
+		}
+		return nil
+	}
+}
+
+// Backpressure creates an ExitHandler that tells upstreams to stop sending,
+// by calling signal, and then waits for the drain window wait, measured on
+// DefaultClock, for in-flight traffic to finish. It suits proxies and
+// gateways, where signal might start setting a draining response header or
+// closing keep-alive connections:
+//
+//	grip.Exit(ch, os.Stderr,
+//		grip.Backpressure(func() error { draining.Store(true); return nil }, 5*time.Second),
+//		grip.Shutdownable(ctx, server),
+//	)
+//
+// If signal fails its error is returned straight away, without waiting, since
+// upstreams may not have been told to stop. signal should be idempotent: it
+// may be called again by a later shutdown attempt.
+func Backpressure(signal func() error, wait time.Duration) ExitHandler {
+	return func() error {
+		if err := signal(); err != nil {
+			return fmt.Errorf("signalling upstreams: %w", err)
+		}
+		sleep(wait)
+		return nil
+	}
+}
+
+// ConsumerShutdown creates an ExitHandler for the shutdown of a message queue
+// consumer, kept broker-agnostic through plain functions. In order, it calls
+// stopFetch to stop receiving new messages, commit to commit the offsets or
+// acknowledgements of what was processed, and closeFn to close the consumer:
+//
+//	grip.ConsumerShutdown(consumer.Pause, consumer.CommitOffsets, consumer.Close, 10*time.Second)
+//
+// closeFn is called even if commit fails, and their errors are joined with
+// errors.Join. The ExitHandler fails with an error wrapping ErrTimeout if the
+// steps have not finished within timeout.
+func ConsumerShutdown(stopFetch func(), commit, closeFn func() error, timeout time.Duration) ExitHandler {
+	return func() error {
+		return runWithTimeout(func(context.Context) error {
+			stopFetch()
+			var errs []error
+			if err := commit(); err != nil {
+				errs = append(errs, fmt.Errorf("commit: %w", err))
+			}
+			if err := closeFn(); err != nil {
+				errs = append(errs, fmt.Errorf("close: %w", err))
+			}
+			return errors.Join(errs...)
+		}, timeout)
+	}
+}
+
+// StopTicker creates an ExitHandler that tears down a ticker-driven loop: it
+// stops t and closes done so the loop returns.
+//
+//	ticker := time.NewTicker(time.Minute)
+//	done := make(chan struct{})
+//	go func() {
+//		for {
+//			select {
+//			case <-ticker.C:
+//				refresh()
+//			case <-done:
+//				return
+//			}
+//		}
+//	}()
+//	grip.Exit(ch, os.Stderr, grip.StopTicker(ticker, done))
+//
+// done is closed only the first time the ExitHandler is called, so calling it
+// again is safe. It fails if done is nil, since there is then no way to tell
+// the loop to stop.
+func StopTicker(t *time.Ticker, done chan<- struct{}) ExitHandler {
+	var once sync.Once
+	return func() error {
+		if t != nil {
+			t.Stop()
+		}
+		if done == nil {
+			return errors.New("nil done channel")
+		}
+		once.Do(func() {
+			close(done)
+		})
+		return nil
+	}
+}
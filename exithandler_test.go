@@ -0,0 +1,800 @@
+//go:build !js
+
+package grip_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/codycraven/grip"
+	"github.com/codycraven/grip/griptest"
+)
+
+func TestCancelAll(t *testing.T) {
+	var order []int
+	var cancels []context.CancelFunc
+	var ctxs []context.Context
+	for i := 0; i < 3; i++ {
+		i := i
+		ctx, cancel := context.WithCancel(context.Background())
+		ctxs = append(ctxs, ctx)
+		cancels = append(cancels, func() {
+			order = append(order, i)
+			cancel()
+		})
+	}
+	if err := grip.CancelAll(cancels...)(); err != nil {
+		t.Fatalf("CancelAll returned %v", err)
+	}
+	for i, ctx := range ctxs {
+		if ctx.Err() == nil {
+			t.Errorf("context %d was not cancelled", i)
+		}
+	}
+	if len(order) != 3 || order[0] != 0 || order[1] != 1 || order[2] != 2 {
+		t.Errorf("cancelled in order %v, want [0 1 2]", order)
+	}
+}
+
+func TestCommand(t *testing.T) {
+	for _, name := range []string{"true", "false"} {
+		if _, err := exec.LookPath(name); err != nil {
+			t.Skipf("%s not found: %v", name, err)
+		}
+	}
+	ch := make(chan int, 1)
+	grip.Exit(ch, nil,
+		grip.Command(context.Background(), "true"),
+		grip.Command(context.Background(), "false"),
+	)(syscall.SIGTERM)
+	if got := <-ch; got != 2 {
+		t.Errorf("exit code %d, want 2", got)
+	}
+}
+
+func TestCommandOutput(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skipf("sh not found: %v", err)
+	}
+	err := grip.Command(context.Background(), "sh", "-c", "echo not registered; exit 3")()
+	if err == nil || !strings.Contains(err.Error(), "not registered") {
+		t.Errorf("error %v does not include the command's output", err)
+	}
+}
+
+func TestWaitFor(t *testing.T) {
+	polls := 0
+	err := grip.WaitFor(func() (bool, error) {
+		polls++
+		return polls == 3, nil
+	}, time.Millisecond, time.Second)()
+	if err != nil {
+		t.Errorf("WaitFor returned %v", err)
+	}
+	if polls != 3 {
+		t.Errorf("polled %d times, want 3", polls)
+	}
+}
+
+func TestWaitForTimeout(t *testing.T) {
+	err := grip.WaitFor(func() (bool, error) { return false, nil }, time.Millisecond, 10*time.Millisecond)()
+	if !errors.Is(err, grip.ErrTimeout) {
+		t.Errorf("WaitFor returned %v, want ErrTimeout", err)
+	}
+}
+
+func TestWaitForPollError(t *testing.T) {
+	want := errors.New("unreachable")
+	err := grip.WaitFor(func() (bool, error) { return false, want }, time.Millisecond, time.Second)()
+	if !errors.Is(err, want) {
+		t.Errorf("WaitFor returned %v, want %v", err, want)
+	}
+}
+
+func TestWaitForInterval(t *testing.T) {
+	for _, interval := range []time.Duration{0, -time.Second} {
+		err := grip.WaitFor(func() (bool, error) {
+			t.Fatal("poll called")
+			return true, nil
+		}, interval, time.Second)()
+		if err == nil {
+			t.Errorf("WaitFor with interval %s returned nil", interval)
+		}
+	}
+}
+
+// fakeServer records the context its Shutdown method is called with.
+type fakeServer struct {
+	ctx context.Context
+	err error
+}
+
+func (s *fakeServer) Shutdown(ctx context.Context) error {
+	s.ctx = ctx
+	return s.err
+}
+
+func TestShutdownable(t *testing.T) {
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "deadline")
+	ok, broken := &fakeServer{}, &fakeServer{err: errors.New("listener busy")}
+	ch := make(chan int, 1)
+	grip.Exit(ch, nil, grip.Shutdownable(ctx, ok), grip.Shutdownable(ctx, broken))(syscall.SIGTERM)
+	if got := <-ch; got != 2 {
+		t.Errorf("exit code %d, want 2", got)
+	}
+	if ok.ctx != ctx || broken.ctx != ctx {
+		t.Error("Shutdown was not called with the provided context")
+	}
+}
+
+func TestDrainChan(t *testing.T) {
+	jobs := make(chan int, 5)
+	for i := 1; i <= 5; i++ {
+		jobs <- i
+	}
+	sum := 0
+	if err := grip.DrainChan(jobs, func(v int) { sum += v }, time.Second)(); err != nil {
+		t.Fatalf("DrainChan returned %v", err)
+	}
+	if sum != 15 || len(jobs) != 0 {
+		t.Errorf("consumed a sum of %d with %d left, want 15 with none left", sum, len(jobs))
+	}
+}
+
+func TestDrainChanTimeout(t *testing.T) {
+	jobs := make(chan int, 5)
+	for i := 0; i < 5; i++ {
+		jobs <- i
+	}
+	consumed := 0
+	err := grip.DrainChan(jobs, func(int) {
+		consumed++
+		time.Sleep(20 * time.Millisecond)
+	}, 30*time.Millisecond)()
+	if !errors.Is(err, grip.ErrTimeout) {
+		t.Errorf("DrainChan returned %v, want ErrTimeout", err)
+	}
+	if consumed == 5 {
+		t.Error("every item was consumed despite the timeout")
+	}
+}
+
+func TestDrainChanClosed(t *testing.T) {
+	jobs := make(chan int)
+	close(jobs)
+	if err := grip.DrainChan(jobs, func(int) {}, time.Second)(); err != nil {
+		t.Errorf("DrainChan returned %v", err)
+	}
+}
+
+func TestEmit(t *testing.T) {
+	var got context.Context
+	err := grip.Emit(func(ctx context.Context) error {
+		got = ctx
+		return nil
+	}, time.Second)()
+	if err != nil {
+		t.Fatalf("Emit returned %v", err)
+	}
+	if _, ok := got.Deadline(); !ok {
+		t.Error("fn's context has no deadline")
+	}
+}
+
+func TestEmitTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	err := grip.Emit(func(ctx context.Context) error {
+		<-release // ignores its context
+		return nil
+	}, 10*time.Millisecond)()
+	if !errors.Is(err, grip.ErrTimeout) {
+		t.Errorf("Emit returned %v, want ErrTimeout", err)
+	}
+}
+
+func TestIfOK(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		flush    error
+		wantCode int
+		wantRan  bool
+	}{
+		{"previous passed", nil, 0, true},
+		{"previous failed", errors.New("flush"), 1, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var flushErr error
+			ran := false
+			results, _ := grip.RunIsolated(
+				grip.Capture(&flushErr, func() error { return tt.flush }),
+				grip.IfOK(&flushErr, func() error {
+					ran = true
+					return nil
+				}),
+			)
+			if ran != tt.wantRan {
+				t.Errorf("dependent step ran = %v, want %v", ran, tt.wantRan)
+			}
+			if got := grip.Bitmask(results); got != tt.wantCode {
+				t.Errorf("exit code %d, want %d", got, tt.wantCode)
+			}
+			if results[1].Skipped == tt.wantRan {
+				t.Errorf("Skipped = %v, want %v", results[1].Skipped, !tt.wantRan)
+			}
+		})
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	results, _ := grip.RunIsolated(
+		grip.Timeout(10*time.Millisecond, func() error {
+			<-release
+			return nil
+		}),
+		grip.Timeout(time.Second, fail),
+		grip.Timeout(time.Second, pass),
+	)
+	if r := results[0]; !r.TimedOut || !r.Failed() {
+		t.Errorf("slow ExitHandler: TimedOut %v, Failed %v, want both", r.TimedOut, r.Failed())
+	}
+	if r := results[1]; r.TimedOut || !r.Failed() {
+		t.Errorf("failing ExitHandler: TimedOut %v, Failed %v, want only Failed", r.TimedOut, r.Failed())
+	}
+	if r := results[2]; r.TimedOut || r.Failed() {
+		t.Errorf("passing ExitHandler: TimedOut %v, Failed %v, want neither", r.TimedOut, r.Failed())
+	}
+	if got := grip.Bitmask(results); got != 3 {
+		t.Errorf("exit code %d, want 3", got)
+	}
+}
+
+func TestAwaitWaitGroup(t *testing.T) {
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		wg.Done()
+	}()
+	if err := grip.AwaitWaitGroup(&wg, time.Second)(); err != nil {
+		t.Errorf("AwaitWaitGroup returned %v", err)
+	}
+}
+
+func TestAwaitWaitGroupTimeout(t *testing.T) {
+	var wg sync.WaitGroup
+	wg.Add(1)
+	defer wg.Done()
+	if err := grip.AwaitWaitGroup(&wg, 10*time.Millisecond)(); !errors.Is(err, grip.ErrTimeout) {
+		t.Errorf("AwaitWaitGroup returned %v, want ErrTimeout", err)
+	}
+}
+
+// closer is an io.Closer returning err and recording that it was closed.
+type closer struct {
+	err    error
+	closed bool
+}
+
+func (c *closer) Close() error {
+	c.closed = true
+	return c.err
+}
+
+func TestMultiClose(t *testing.T) {
+	errA, errC := errors.New("a"), errors.New("c")
+	a, b, c := &closer{err: errA}, &closer{}, &closer{err: errC}
+	err := grip.MultiClose(a, b, c)()
+	if !a.closed || !b.closed || !c.closed {
+		t.Error("not every io.Closer was closed")
+	}
+	if !errors.Is(err, errA) || !errors.Is(err, errC) {
+		t.Errorf("MultiClose returned %v, want both errors", err)
+	}
+}
+
+func TestCloseAll(t *testing.T) {
+	ch := make(chan int, 1)
+	grip.Exit(ch, nil, grip.CloseAll(&closer{}, &closer{err: errors.New("b")}, &closer{})...)(syscall.SIGTERM)
+	if got := <-ch; got != 2 {
+		t.Errorf("exit code %d, want 2", got)
+	}
+}
+
+// fakeConn is an io.Writer with a write deadline, like a net.Conn.
+type fakeConn struct {
+	bytes.Buffer
+	deadline time.Time
+	err      error
+}
+
+func (c *fakeConn) SetWriteDeadline(t time.Time) error {
+	c.deadline = t
+	return nil
+}
+
+func (c *fakeConn) Write(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	return c.Buffer.Write(p)
+}
+
+func TestNotify(t *testing.T) {
+	conn := &fakeConn{}
+	if err := grip.Notify(conn, []byte("stopping\n"), time.Second)(); err != nil {
+		t.Fatalf("Notify returned %v", err)
+	}
+	if conn.String() != "stopping\n" {
+		t.Errorf("wrote %q, want %q", conn.String(), "stopping\n")
+	}
+	if conn.deadline.IsZero() {
+		t.Error("no write deadline was set")
+	}
+}
+
+func TestNotifyError(t *testing.T) {
+	errBroken := errors.New("broken pipe")
+	ch := make(chan int, 1)
+	ran := false
+	grip.Exit(ch, nil,
+		grip.Notify(&fakeConn{err: errBroken}, []byte("stopping\n"), time.Second),
+		func() error {
+			ran = true
+			return nil
+		},
+	)(syscall.SIGTERM)
+	if got := <-ch; got != 1 {
+		t.Errorf("exit code %d, want 1", got)
+	}
+	if !ran {
+		t.Error("the remaining ExitHandlers did not run")
+	}
+}
+
+// startPool starts workers reading from jobs, each sleeping for work per job
+// and reporting on done when it returns.
+func startPool(workers int, work time.Duration) (chan struct{}, chan struct{}) {
+	jobs, done := make(chan struct{}), make(chan struct{}, workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer func() { done <- struct{}{} }()
+			for range jobs {
+				time.Sleep(work)
+			}
+		}()
+	}
+	return jobs, done
+}
+
+func TestDrainPool(t *testing.T) {
+	jobs, done := startPool(4, 5*time.Millisecond)
+	for i := 0; i < 4; i++ {
+		jobs <- struct{}{}
+	}
+	if err := grip.DrainPool(jobs, 4, done, time.Second)(); err != nil {
+		t.Errorf("DrainPool returned %v", err)
+	}
+}
+
+func TestDrainPoolTimeout(t *testing.T) {
+	jobs, done := startPool(2, 100*time.Millisecond)
+	jobs <- struct{}{}
+	err := grip.DrainPool(jobs, 2, done, 20*time.Millisecond)()
+	if !errors.Is(err, grip.ErrTimeout) {
+		t.Errorf("DrainPool returned %v, want ErrTimeout", err)
+	}
+	if err == nil || !strings.Contains(err.Error(), "1 of 2 workers") {
+		t.Errorf("error %v does not count the worker still running", err)
+	}
+}
+
+func TestDrainPoolTwice(t *testing.T) {
+	jobs, done := make(chan struct{}), make(chan struct{}, 2)
+	release := make(chan struct{})
+	go func() {
+		defer func() { done <- struct{}{} }()
+		for range jobs {
+		}
+	}()
+	go func() {
+		defer func() { done <- struct{}{} }()
+		<-release
+	}()
+	drain := grip.DrainPool(jobs, 2, done, 20*time.Millisecond)
+	if err := drain(); !errors.Is(err, grip.ErrTimeout) {
+		t.Fatalf("first call returned %v, want ErrTimeout", err)
+	}
+	// Calling it again must not close jobs a second time, and waits only for
+	// the worker still running.
+	close(release)
+	if err := drain(); err != nil {
+		t.Errorf("second call returned %v", err)
+	}
+	if err := drain(); err != nil {
+		t.Errorf("third call returned %v", err)
+	}
+}
+
+func TestForSignal(t *testing.T) {
+	var ran []string
+	record := func(name string) grip.ExitHandler {
+		return func() error {
+			ran = append(ran, name)
+			return nil
+		}
+	}
+	ch := make(chan int, 1)
+	h := grip.ExitCtx(ch, nil,
+		grip.ForSignal(syscall.SIGHUP, record("reload")),
+		grip.ForSignal(syscall.SIGTERM, record("shutdown")),
+		func(ctx context.Context) error {
+			if s, ok := grip.SignalFrom(ctx); ok {
+				ran = append(ran, s.String())
+			} else {
+				ran = append(ran, "no signal")
+			}
+			return nil
+		},
+	)
+	tests := []struct {
+		sig  os.Signal
+		want []string
+	}{
+		{syscall.SIGHUP, []string{"reload", "hangup"}},
+		{syscall.SIGTERM, []string{"shutdown", "terminated"}},
+		{nil, []string{"no signal"}},
+	}
+	for _, tt := range tests {
+		ran = nil
+		h(tt.sig)
+		if code := <-ch; code != 0 {
+			t.Errorf("%v: exit code %d, want skipped ExitHandlers not to fail", tt.sig, code)
+		}
+		if !slices.Equal(ran, tt.want) {
+			t.Errorf("%v: ran %q, want %q", tt.sig, ran, tt.want)
+		}
+	}
+}
+
+func TestNamedExitHandlerSignal(t *testing.T) {
+	var ran []string
+	h := grip.New(grip.WithSignals(syscall.SIGHUP, syscall.SIGTERM), grip.WithNamedExitHandlers(
+		grip.NamedExitHandler{Name: "reload", Signal: syscall.SIGHUP, Fn: func() error {
+			ran = append(ran, "reload")
+			return nil
+		}},
+		grip.NamedExitHandler{Name: "shutdown", Signal: syscall.SIGTERM, Fn: func() error {
+			ran = append(ran, "shutdown")
+			return nil
+		}},
+	))
+	for _, tt := range []struct {
+		sig  os.Signal
+		want string
+	}{
+		{syscall.SIGHUP, "reload"},
+		{syscall.SIGTERM, "shutdown"},
+	} {
+		ran = nil
+		ch := make(chan int, 1)
+		trap := h.Trap(ch)
+		trap.Trigger(tt.sig)
+		if code := <-ch; code != 0 {
+			t.Errorf("%v: exit code %d, want 0", tt.sig, code)
+		}
+		trap.Stop()
+		if len(ran) != 1 || ran[0] != tt.want {
+			t.Errorf("%v: ran %q, want [%s]", tt.sig, ran, tt.want)
+		}
+	}
+}
+
+func TestDrainCounter(t *testing.T) {
+	var active atomic.Int64
+	active.Store(3)
+	go func() {
+		for i := 0; i < 3; i++ {
+			time.Sleep(5 * time.Millisecond)
+			active.Add(-1)
+		}
+	}()
+	if err := grip.DrainCounter(&active, time.Millisecond, time.Second)(); err != nil {
+		t.Errorf("DrainCounter() = %v, want nil once the counter reached zero", err)
+	}
+}
+
+func TestDrainCounterTimeout(t *testing.T) {
+	var active atomic.Int64
+	active.Store(2)
+	err := grip.DrainCounter(&active, time.Millisecond, 20*time.Millisecond)()
+	if !errors.Is(err, grip.ErrTimeout) || !strings.HasPrefix(err.Error(), "2 still active") {
+		t.Errorf("DrainCounter() = %v, want a timeout reporting 2 still active", err)
+	}
+	ch := make(chan int, 1)
+	grip.Exit(ch, nil, pass, grip.DrainCounter(&active, time.Millisecond, 10*time.Millisecond))(syscall.SIGTERM)
+	if code := <-ch; code != 2 {
+		t.Errorf("exit code %d, want the bit of the timed out ExitHandler, 2", code)
+	}
+}
+
+func TestReopenFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	var (
+		mu  sync.Mutex
+		log *os.File
+	)
+	reopen := grip.ReopenFile(path, &log, &mu)
+	if err := reopen(); err != nil {
+		t.Fatal(err)
+	}
+	first := log
+	fmt.Fprintln(log, "before")
+
+	rotated := filepath.Join(dir, "app.log.1")
+	if err := os.Rename(path, rotated); err != nil {
+		t.Fatal(err)
+	}
+	if err := reopen(); err != nil {
+		t.Fatal(err)
+	}
+	defer log.Close()
+	fmt.Fprintln(log, "after")
+	if _, err := first.Write([]byte("x")); err == nil {
+		t.Error("previous file was not closed")
+	}
+
+	for file, want := range map[string]string{rotated: "before\n", path: "after\n"} {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != want {
+			t.Errorf("%s contains %q, want %q", filepath.Base(file), b, want)
+		}
+	}
+}
+
+func TestReopenFileError(t *testing.T) {
+	var mu sync.Mutex
+	prev, err := os.CreateTemp(t.TempDir(), "log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer prev.Close()
+	log := prev
+	missing := filepath.Join(t.TempDir(), "missing", "app.log")
+	if err := grip.ReopenFile(missing, &log, &mu)(); err == nil {
+		t.Error("ReopenFile() = nil for a path that cannot be created")
+	}
+	if log != prev {
+		t.Error("target replaced although opening failed")
+	}
+}
+
+func TestBackpressure(t *testing.T) {
+	clock := griptest.NewFakeClock(t)
+	var signalled atomic.Bool
+	done := make(chan error, 1)
+	go func() {
+		done <- grip.Backpressure(func() error {
+			signalled.Store(true)
+			return nil
+		}, 5*time.Second)()
+	}()
+	waitPending(t, clock, 1)
+	if !signalled.Load() {
+		t.Fatal("upstreams were not signalled before the wait")
+	}
+	clock.Advance(4 * time.Second)
+	select {
+	case <-done:
+		t.Fatal("returned before the drain window ended")
+	default:
+	}
+	clock.Advance(time.Second)
+	if err := <-done; err != nil {
+		t.Errorf("Backpressure() = %v, want nil", err)
+	}
+}
+
+func TestBackpressureError(t *testing.T) {
+	clock := griptest.NewFakeClock(t)
+	refused := errors.New("refused")
+	err := grip.Backpressure(func() error { return refused }, time.Hour)()
+	if !errors.Is(err, refused) || !strings.HasPrefix(err.Error(), "signalling upstreams: ") {
+		t.Errorf("Backpressure() = %v, want the signal error", err)
+	}
+	if clock.Pending() != 0 {
+		t.Error("waited although signalling failed")
+	}
+}
+
+func TestUpload(t *testing.T) {
+	var got []byte
+	err := grip.Upload(func(ctx context.Context) error {
+		if _, ok := ctx.Deadline(); !ok {
+			t.Error("upload context has no deadline")
+		}
+		got = []byte("logs")
+		return nil
+	}, time.Second)()
+	if err != nil || string(got) != "logs" {
+		t.Errorf("Upload() = %v, uploaded %q, want nil and logs", err, got)
+	}
+
+	// Upload waits for fn to abort rather than leaving it running.
+	aborted := false
+	err = grip.Upload(func(ctx context.Context) error {
+		<-ctx.Done()
+		time.Sleep(10 * time.Millisecond)
+		aborted = true
+		return ctx.Err()
+	}, 10*time.Millisecond)()
+	if !errors.Is(err, grip.ErrTimeout) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Upload() = %v, want a timeout", err)
+	}
+	if !aborted {
+		t.Error("Upload returned before fn did")
+	}
+}
+
+func TestUploadRetry(t *testing.T) {
+	flaky := func(failures int32) (func(context.Context) error, *atomic.Int32) {
+		calls := new(atomic.Int32)
+		return func(context.Context) error {
+			if n := calls.Add(1); n <= failures {
+				return fmt.Errorf("attempt %d: unavailable", n)
+			}
+			return nil
+		}, calls
+	}
+
+	fn, calls := flaky(2)
+	if err := grip.UploadRetry(fn, time.Second, 3, time.Millisecond)(); err != nil || calls.Load() != 3 {
+		t.Errorf("UploadRetry() = %v after %d attempts, want success on the third", err, calls.Load())
+	}
+
+	fn, calls = flaky(5)
+	err := grip.UploadRetry(fn, time.Second, 3, time.Millisecond)()
+	if err == nil || err.Error() != "attempt 3: unavailable" || calls.Load() != 3 {
+		t.Errorf("UploadRetry() = %v after %d attempts, want the third attempt's error", err, calls.Load())
+	}
+
+	// The timeout covers the attempts and the backoff between them.
+	fn, calls = flaky(5)
+	err = grip.UploadRetry(fn, 20*time.Millisecond, 10, time.Hour)()
+	if !errors.Is(err, grip.ErrTimeout) || calls.Load() != 1 {
+		t.Errorf("UploadRetry() = %v after %d attempts, want a timeout during the first backoff", err, calls.Load())
+	}
+}
+
+func TestConsumerShutdown(t *testing.T) {
+	var order []string
+	step := func(name string, err error) func() error {
+		return func() error {
+			order = append(order, name)
+			return err
+		}
+	}
+	stopFetch := func() { order = append(order, "stop") }
+
+	err := grip.ConsumerShutdown(stopFetch, step("commit", nil), step("close", nil), time.Second)()
+	if err != nil || !slices.Equal(order, []string{"stop", "commit", "close"}) {
+		t.Errorf("ConsumerShutdown() = %v, ran %q, want nil after stop, commit, close", err, order)
+	}
+
+	order = nil
+	errCommit, errClose := errors.New("rebalancing"), errors.New("broken pipe")
+	err = grip.ConsumerShutdown(stopFetch, step("commit", errCommit), step("close", errClose), time.Second)()
+	if !slices.Equal(order, []string{"stop", "commit", "close"}) {
+		t.Errorf("ran %q, want close despite the failed commit", order)
+	}
+	if !errors.Is(err, errCommit) || !errors.Is(err, errClose) || err.Error() != "commit: rebalancing\nclose: broken pipe" {
+		t.Errorf("ConsumerShutdown() = %q, want both errors joined", err)
+	}
+}
+
+func TestConsumerShutdownTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	err := grip.ConsumerShutdown(func() {}, func() error {
+		<-release
+		return nil
+	}, pass, 10*time.Millisecond)()
+	if !errors.Is(err, grip.ErrTimeout) {
+		t.Errorf("ConsumerShutdown() = %v, want a timeout", err)
+	}
+}
+
+func TestAwaitFileGone(t *testing.T) {
+	lock := filepath.Join(t.TempDir(), "migrate.lock")
+	if err := os.WriteFile(lock, nil, 0o666); err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		os.Remove(lock)
+	}()
+	if err := grip.AwaitFileGone(lock, time.Millisecond, time.Second)(); err != nil {
+		t.Errorf("AwaitFileGone() = %v, want nil once the file was removed", err)
+	}
+	if err := grip.AwaitFileGone(lock, time.Hour, time.Hour)(); err != nil {
+		t.Errorf("AwaitFileGone() = %v for an absent file, want nil", err)
+	}
+}
+
+func TestAwaitFileGoneTimeout(t *testing.T) {
+	lock := filepath.Join(t.TempDir(), "migrate.lock")
+	if err := os.WriteFile(lock, nil, 0o666); err != nil {
+		t.Fatal(err)
+	}
+	err := grip.AwaitFileGone(lock, time.Millisecond, 20*time.Millisecond)()
+	if !errors.Is(err, grip.ErrTimeout) || !strings.HasPrefix(err.Error(), lock+" still exists after 20ms") {
+		t.Errorf("AwaitFileGone() = %v, want a timeout naming the file", err)
+	}
+}
+
+func TestStopTicker(t *testing.T) {
+	ticker := time.NewTicker(time.Millisecond)
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case <-ticker.C:
+			case <-done:
+				return
+			}
+		}
+	}()
+	stop := grip.StopTicker(ticker, done)
+	for i := 0; i < 2; i++ {
+		if err := stop(); err != nil {
+			t.Fatalf("call %d: StopTicker() = %v, want nil", i+1, err)
+		}
+	}
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("loop did not return")
+	}
+	// A stopped ticker does not tick; drain a tick sent before Stop.
+	select {
+	case <-ticker.C:
+	default:
+	}
+	select {
+	case <-ticker.C:
+		t.Error("ticker still running")
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestStopTickerNil(t *testing.T) {
+	if err := grip.StopTicker(nil, nil)(); err == nil {
+		t.Error("StopTicker() = nil with a nil done channel")
+	}
+	done := make(chan struct{})
+	if err := grip.StopTicker(nil, done)(); err != nil {
+		t.Errorf("StopTicker() = %v with a nil ticker, want nil", err)
+	}
+	<-done
+}
@@ -1,3 +1,3 @@
 module github.com/codycraven/grip
 
-go 1.18
+go 1.21
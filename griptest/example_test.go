@@ -0,0 +1,45 @@
+package griptest_test
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/codycraven/grip"
+	"github.com/codycraven/grip/griptest"
+)
+
+func ExampleSend() {
+	received := make(chan struct{})
+	h := grip.TrapRepeat(func(s os.Signal) {
+		fmt.Println("received", s)
+		close(received)
+	}, os.Interrupt)
+	defer h.Stop()
+
+	griptest.Send(os.Interrupt)
+	<-received
+	// Output: received interrupt
+}
+
+func ExampleFakeClock_Advance() {
+	// A zero FakeClock can be used directly. NewFakeClock also installs it as
+	// grip.DefaultClock for the duration of a test.
+	var clock griptest.FakeClock
+	clock.AfterFunc(2*time.Second, func() { fmt.Println("second") })
+	clock.AfterFunc(time.Second, func() {
+		fmt.Println("first")
+		clock.AfterFunc(0, func() { fmt.Println("scheduled by first") })
+	})
+	stopped := clock.AfterFunc(time.Second, func() { fmt.Println("stopped") })
+	stopped.Stop()
+
+	clock.Advance(time.Second)
+	fmt.Println("pending:", clock.Pending())
+	clock.Advance(time.Second)
+	// Output:
+	// first
+	// scheduled by first
+	// pending: 1
+	// second
+}
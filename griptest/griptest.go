@@ -0,0 +1,170 @@
+// Package griptest provides utilities for testing shutdown logic built on
+// grip without sending real signals or exiting the test binary.
+//
+//	func TestShutdown(t *testing.T) {
+//		exits := griptest.CaptureExit(t)
+//		go grip.Default(closeDB)
+//		griptest.WaitRegistered(t, syscall.SIGTERM)
+//		griptest.Send(syscall.SIGTERM)
+//		if code := <-exits; code != 0 {
+//			t.Fatalf("exit code %d", code)
+//		}
+//	}
+package griptest
+
+import (
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/codycraven/grip"
+	"github.com/codycraven/grip/internal/seam"
+)
+
+// Send delivers sig to every channel grip has registered for it, exactly as
+// if the process had received it, and reports whether any channel was
+// registered and ready. No real signal is sent, so signals that would kill the
+// process, such as SIGTERM, are safe to send whether or not grip traps them.
+//
+// Like os/signal, Send does not block: a channel that already holds a signal
+// does not receive sig.
+func Send(sig os.Signal) bool {
+	return seam.Deliver(sig) > 0
+}
+
+// WaitRegistered waits up to a second for grip to register a channel ready
+// for sig, failing t otherwise. It covers the race between starting a
+// goroutine that traps signals, such as grip.Default, and calling Send.
+func WaitRegistered(t testing.TB, sig os.Signal) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !seam.Registered(sig) {
+		if time.Now().After(deadline) {
+			t.Fatalf("griptest: %s was not registered", sig)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// CaptureExit replaces grip.ExitFunc for the duration of the test and returns
+// a channel receiving each exit code it is called with. The original ExitFunc
+// is restored when the test ends.
+//
+// Unlike os.Exit the replacement returns, so code following the call keeps
+// running. The channel holds up to 16 exit codes; further ones are dropped.
+func CaptureExit(t testing.TB) <-chan int {
+	t.Helper()
+	codes := make(chan int, 16)
+	orig := grip.ExitFunc
+	grip.ExitFunc = func(code int) {
+		select {
+		case codes <- code:
+		default:
+		}
+	}
+	t.Cleanup(func() {
+		grip.ExitFunc = orig
+	})
+	return codes
+}
+
+// A FakeClock is a grip.Clock whose time only moves when Advance is called,
+// making timeouts, deadlines and grace periods deterministic.
+type FakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+// NewFakeClock creates a FakeClock and installs it as grip.DefaultClock for
+// the duration of the test, restoring the original when the test ends.
+func NewFakeClock(t testing.TB) *FakeClock {
+	t.Helper()
+	c := &FakeClock{now: time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)}
+	orig := grip.DefaultClock
+	grip.DefaultClock = c
+	t.Cleanup(func() {
+		grip.DefaultClock = orig
+	})
+	return c
+}
+
+// Now returns the FakeClock's current time.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// AfterFunc schedules f to be called once the FakeClock has been advanced by
+// d. Unlike with the system clock, f is called by Advance itself rather than in
+// a goroutine of its own.
+func (c *FakeClock) AfterFunc(d time.Duration, f func()) grip.Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	timer := &fakeTimer{clock: c, at: c.now.Add(d), f: f}
+	c.timers = append(c.timers, timer)
+	return timer
+}
+
+// Advance moves the FakeClock forward by d and calls every timer that has come
+// due, one after the other in the order they are due, before returning. Timers
+// due at the same time are called in the order they were scheduled, and timers
+// scheduled by those calls are called too if they are already due.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+	for {
+		timer := c.next()
+		if timer == nil {
+			return
+		}
+		timer.f()
+	}
+}
+
+// next removes and returns the earliest timer that is due, or nil if none is.
+func (c *FakeClock) next() *fakeTimer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	next := -1
+	for i, timer := range c.timers {
+		if !timer.at.After(c.now) && (next < 0 || timer.at.Before(c.timers[next].at)) {
+			next = i
+		}
+	}
+	if next < 0 {
+		return nil
+	}
+	timer := c.timers[next]
+	c.timers = append(c.timers[:next], c.timers[next+1:]...)
+	return timer
+}
+
+// Pending returns the number of timers that have not fired or been stopped,
+// so a test can wait for a SignalHandler to schedule one before advancing.
+func (c *FakeClock) Pending() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.timers)
+}
+
+type fakeTimer struct {
+	clock *FakeClock
+	at    time.Time
+	f     func()
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	for i, timer := range t.clock.timers {
+		if timer == t {
+			t.clock.timers = append(t.clock.timers[:i], t.clock.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
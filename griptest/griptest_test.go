@@ -0,0 +1,80 @@
+//go:build unix
+
+package griptest_test
+
+import (
+	"os"
+	"reflect"
+	"slices"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/codycraven/grip"
+	"github.com/codycraven/grip/griptest"
+)
+
+func TestCaptureExit(t *testing.T) {
+	orig := grip.ExitFunc
+	t.Run("capture", func(t *testing.T) {
+		exits := griptest.CaptureExit(t)
+		grip.ExitFunc(3)
+		grip.ExitFunc(4)
+		if a, b := <-exits, <-exits; a != 3 || b != 4 {
+			t.Errorf("captured %d, %d, want 3, 4", a, b)
+		}
+	})
+	if reflect.ValueOf(grip.ExitFunc).Pointer() != reflect.ValueOf(orig).Pointer() {
+		t.Error("ExitFunc not restored after the test")
+	}
+}
+
+func TestSend(t *testing.T) {
+	if griptest.Send(syscall.SIGUSR2) {
+		t.Error("Send reported a delivery without a registered channel")
+	}
+	received := make(chan os.Signal, 1)
+	h := grip.TrapRepeat(func(s os.Signal) { received <- s }, syscall.SIGUSR2)
+	t.Cleanup(h.Stop)
+	griptest.WaitRegistered(t, syscall.SIGUSR2)
+	if !griptest.Send(syscall.SIGUSR2) {
+		t.Error("Send did not deliver to the registered channel")
+	}
+	select {
+	case s := <-received:
+		if s != syscall.SIGUSR2 {
+			t.Errorf("received %s, want SIGUSR2", s)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("signal not received")
+	}
+}
+
+func TestFakeClock(t *testing.T) {
+	orig := grip.DefaultClock
+	t.Run("install", func(t *testing.T) {
+		clock := griptest.NewFakeClock(t)
+		if grip.DefaultClock != grip.Clock(clock) {
+			t.Fatal("NewFakeClock did not install the FakeClock")
+		}
+		start := clock.Now()
+		var order []int
+		for _, d := range []time.Duration{3, 1, 2, 1} {
+			d := d
+			clock.AfterFunc(d*time.Second, func() { order = append(order, int(d)) })
+		}
+		clock.Advance(2 * time.Second)
+		if !slices.Equal(order, []int{1, 1, 2}) {
+			t.Errorf("fired %v, want [1 1 2] in due order", order)
+		}
+		if got := clock.Now().Sub(start); got != 2*time.Second {
+			t.Errorf("clock advanced %v, want 2s", got)
+		}
+		if clock.Pending() != 1 {
+			t.Errorf("%d timers pending, want 1", clock.Pending())
+		}
+	})
+	if grip.DefaultClock != orig {
+		t.Error("DefaultClock not restored after the test")
+	}
+}
@@ -2,10 +2,8 @@
 package grip
 
 import (
-	"fmt"
 	"io"
 	"os"
-	"os/signal"
 )
 
 // A SignalHandler receives a signal and does something with it.
@@ -14,19 +12,26 @@ type SignalHandler func(os.Signal)
 // An ExitHandler performs actions and returns an error if a problem occurs.
 type ExitHandler func() error
 
+// ExitFunc terminates the process for SignalHandlers that exit on their own
+// rather than passing an exit code to a channel. It defaults to os.Exit and can
+// be replaced, for example to observe the exit code in tests.
+var ExitFunc = os.Exit
+
 // Trap listens for provided os.Signals and executes a SignalHandler callback
-// function when one is received.
-func Trap(fn SignalHandler, s ...os.Signal) {
-	ch := make(chan os.Signal, 1)
-	signal.Notify(ch, s...)
-	go func() {
-		fn(<-ch)
-	}()
+// function when one is received. As with signal.Notify, providing no
+// os.Signals traps every signal.
+//
+// The returned TrapHandle can be used to control the trap, but may be ignored.
+func Trap(fn SignalHandler, s ...os.Signal) *TrapHandle {
+	return trap(fn, nil, false, s)
 }
 
 // Message creates a SignalHandler that writes to an io.Writer and then chains
 // to another SignalHandler.
 //
+// A nil io.Writer discards the message. The same is true of every io.Writer
+// accepted by this package, including the error writers of the Exit family.
+//
 //	grip.Trap(
 //		grip.Message("received shutdown request", os.Stdout, func(_ os.Signal) {
 //			fmt.Println("our signal handler")
@@ -34,10 +39,7 @@ func Trap(fn SignalHandler, s ...os.Signal) {
 //		syscall.SIGINT, syscall.SIGTERM,
 //	)
 func Message(m string, w io.Writer, fn SignalHandler) SignalHandler {
-	return func(s os.Signal) {
-		fmt.Fprintf(w, "%s: %s\n", m, s)
-		fn(s)
-	}
+	return MessageTo(WriterSink(w), m, fn)
 }
 
 // Exit creates a SignalHandler that passes exit codes to a channel.
@@ -46,6 +48,11 @@ func Message(m string, w io.Writer, fn SignalHandler) SignalHandler {
 // to the channel is incremented in a base-2 manner so that when receiving the
 // exit code you can determine which ExitHandler(s) failed.
 //
+// If the SignalHandler is called again while its ExitHandlers are still
+// running, for example by a second signal, the second call is written to
+// errWriter and dropped rather than starting an overlapping sequence. The same
+// applies to the other functions of the Exit family.
+//
 // If you receive 6 as your exit code then you can determine which step failed
 // based on bitmasking:
 //
@@ -104,17 +111,27 @@ func Message(m string, w io.Writer, fn SignalHandler) SignalHandler {
 //		os.Exit(<-ch)
 //	}
 func Exit(ch chan int, errWriter io.Writer, fn ...ExitHandler) SignalHandler {
-	return func(s os.Signal) {
-		exit := 0
-		errBit := 1
-		for _, f := range fn {
-			err := f()
-			if err != nil {
-				exit += errBit
-				fmt.Fprintf(errWriter, "added %d to exit code for error: %s\n", errBit, err)
-			}
-			errBit *= 2
-		}
-		ch <- exit
+	return exclusive(WriterSink(errWriter), func(s os.Signal) {
+		ch <- exitCode(errWriter, fn)
+	})
+}
+
+// exitCode calls each ExitHandler in order and returns the bitmask exit code
+// described by Exit.
+func exitCode(errWriter io.Writer, fn []ExitHandler) int {
+	return Bitmask(runExitHandlers(fn, bitReporter(errWriter)))
+}
+
+// bitReporter returns a function that writes a failed HandlerResult to
+// errWriter along with the bit it adds to the exit code, through WriterSink.
+func bitReporter(errWriter io.Writer) func(HandlerResult) {
+	return sinkReporter(WriterSink(errWriter))
+}
+
+// orDiscard returns w, or io.Discard if w is nil.
+func orDiscard(w io.Writer) io.Writer {
+	if w == nil {
+		return io.Discard
 	}
+	return w
 }
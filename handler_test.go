@@ -0,0 +1,24 @@
+package grip_test
+
+import (
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/codycraven/grip"
+)
+
+func TestNilWriters(t *testing.T) {
+	ch := make(chan int, 1)
+	called := false
+	grip.Message("received shutdown request", nil, func(s os.Signal) {
+		called = true
+		grip.Exit(ch, nil, func() error { return os.ErrClosed })(s)
+	})(syscall.SIGTERM)
+	if !called {
+		t.Error("Message did not call the next SignalHandler")
+	}
+	if got := <-ch; got != 1 {
+		t.Errorf("exit code %d, want 1", got)
+	}
+}
@@ -0,0 +1,127 @@
+// Package seam routes grip's os/signal calls through one place, so that
+// griptest can deliver signals to the channels grip has registered without
+// sending real signals to the process.
+package seam
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+)
+
+// OS holds the os/signal functions the seam calls after recording what was
+// registered.
+var OS = struct {
+	Notify func(c chan<- os.Signal, sig ...os.Signal)
+	Stop   func(c chan<- os.Signal)
+	Reset  func(sig ...os.Signal)
+	Ignore func(sig ...os.Signal)
+}{
+	Notify: signal.Notify,
+	Stop:   signal.Stop,
+	Reset:  signal.Reset,
+	Ignore: signal.Ignore,
+}
+
+var (
+	mu sync.Mutex
+	// registered maps each channel passed to Notify to its signals. A channel
+	// without signals receives every signal, as with signal.Notify.
+	registered = make(map[chan<- os.Signal][]os.Signal)
+)
+
+// Notify records c as registered for sig and calls signal.Notify.
+func Notify(c chan<- os.Signal, sig ...os.Signal) {
+	mu.Lock()
+	if s, ok := registered[c]; len(sig) == 0 {
+		registered[c] = nil
+	} else if !ok || len(s) > 0 {
+		registered[c] = append(s, sig...)
+	}
+	mu.Unlock()
+	OS.Notify(c, sig...)
+}
+
+// Stop forgets c and calls signal.Stop.
+func Stop(c chan<- os.Signal) {
+	mu.Lock()
+	delete(registered, c)
+	mu.Unlock()
+	OS.Stop(c)
+}
+
+// Reset forgets sig for every channel and calls signal.Reset.
+func Reset(sig ...os.Signal) {
+	forget(sig)
+	OS.Reset(sig...)
+}
+
+// Ignore forgets sig for every channel and calls signal.Ignore.
+func Ignore(sig ...os.Signal) {
+	forget(sig)
+	OS.Ignore(sig...)
+}
+
+// forget removes sig from every registered channel, or every signal if sig is
+// empty, as with signal.Reset and signal.Ignore.
+func forget(sig []os.Signal) {
+	mu.Lock()
+	defer mu.Unlock()
+	if len(sig) == 0 {
+		clear(registered)
+		return
+	}
+	for c, s := range registered {
+		var kept []os.Signal
+		for _, v := range s {
+			if !contains(sig, v) {
+				kept = append(kept, v)
+			}
+		}
+		if len(kept) == 0 && len(s) > 0 {
+			delete(registered, c)
+		} else {
+			registered[c] = kept
+		}
+	}
+}
+
+// Deliver sends sig to every channel registered for it, without blocking, as
+// os/signal does, and returns how many channels it was sent to.
+func Deliver(sig os.Signal) int {
+	mu.Lock()
+	defer mu.Unlock()
+	n := 0
+	for c, s := range registered {
+		if len(s) > 0 && !contains(s, sig) {
+			continue
+		}
+		select {
+		case c <- sig:
+			n++
+		default:
+		}
+	}
+	return n
+}
+
+func contains(s []os.Signal, sig os.Signal) bool {
+	for _, v := range s {
+		if v == sig {
+			return true
+		}
+	}
+	return false
+}
+
+// Registered reports whether any channel is registered for sig.
+func Registered(sig os.Signal) bool {
+	mu.Lock()
+	defer mu.Unlock()
+	for _, s := range registered {
+		if len(s) == 0 || contains(s, sig) {
+			return true
+		}
+	}
+	return false
+}
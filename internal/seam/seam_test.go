@@ -0,0 +1,97 @@
+//go:build unix
+
+package seam
+
+import (
+	"os"
+	"slices"
+	"syscall"
+	"testing"
+)
+
+// fakeOS replaces OS for the duration of the test, so nothing reaches
+// os/signal, and forgets all registrations when the test ends.
+func fakeOS(t *testing.T) {
+	orig := OS
+	OS.Notify = func(chan<- os.Signal, ...os.Signal) {}
+	OS.Stop = func(chan<- os.Signal) {}
+	OS.Reset = func(...os.Signal) {}
+	OS.Ignore = func(...os.Signal) {}
+	t.Cleanup(func() {
+		OS = orig
+		forget(nil)
+	})
+}
+
+func TestNotifyDeliver(t *testing.T) {
+	fakeOS(t)
+	hup, all := make(chan os.Signal, 1), make(chan os.Signal, 1)
+	Notify(hup, syscall.SIGHUP)
+	Notify(all)
+
+	if !Registered(syscall.SIGHUP) || !Registered(syscall.SIGUSR1) {
+		t.Error("Registered is false for a registered signal")
+	}
+	if n := Deliver(syscall.SIGHUP); n != 2 {
+		t.Errorf("delivered SIGHUP to %d channels, want 2", n)
+	}
+	// Both channels are full, so the signal is dropped as by os/signal.
+	if n := Deliver(syscall.SIGHUP); n != 0 {
+		t.Errorf("delivered SIGHUP to %d full channels, want 0", n)
+	}
+	<-hup
+	<-all
+	if n := Deliver(syscall.SIGUSR1); n != 1 || len(all) != 1 {
+		t.Errorf("delivered SIGUSR1 to %d channels, want only the one registered for every signal", n)
+	}
+}
+
+func TestNotifyAddsSignals(t *testing.T) {
+	fakeOS(t)
+	c := make(chan os.Signal, 1)
+	Notify(c, syscall.SIGHUP)
+	Notify(c, syscall.SIGTERM)
+	if got := registered[c]; !slices.Equal(got, []os.Signal{syscall.SIGHUP, syscall.SIGTERM}) {
+		t.Errorf("registered %v, want [SIGHUP SIGTERM]", got)
+	}
+	// A channel registered for every signal stays so.
+	Notify(c)
+	Notify(c, syscall.SIGINT)
+	if got, ok := registered[c]; !ok || got != nil {
+		t.Errorf("registered %v, want every signal", got)
+	}
+}
+
+func TestStop(t *testing.T) {
+	fakeOS(t)
+	c := make(chan os.Signal, 1)
+	Notify(c, syscall.SIGHUP)
+	Stop(c)
+	if Registered(syscall.SIGHUP) {
+		t.Error("SIGHUP registered after Stop")
+	}
+}
+
+func TestReset(t *testing.T) {
+	fakeOS(t)
+	c := make(chan os.Signal, 1)
+	Notify(c, syscall.SIGHUP, syscall.SIGTERM)
+	Reset(syscall.SIGHUP)
+	if Registered(syscall.SIGHUP) || !Registered(syscall.SIGTERM) {
+		t.Error("Reset did not forget only SIGHUP")
+	}
+	Reset(syscall.SIGTERM)
+	if _, ok := registered[c]; ok {
+		t.Error("channel without remaining signals is still registered")
+	}
+}
+
+func TestIgnoreAll(t *testing.T) {
+	fakeOS(t)
+	Notify(make(chan os.Signal, 1), syscall.SIGHUP)
+	Notify(make(chan os.Signal, 1))
+	Ignore()
+	if Registered(syscall.SIGHUP) || len(registered) != 0 {
+		t.Error("Ignore without signals did not forget every signal")
+	}
+}
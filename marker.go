@@ -0,0 +1,72 @@
+package grip
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Marker supports crash-only recovery with a marker file at path that exists
+// only while the process is running. start writes it, recording the process
+// ID and start time, and clean removes it during shutdown. A marker found at
+// startup therefore means the previous run did not shut down cleanly:
+//
+//	start, clean := grip.Marker("/var/lib/app/running")
+//	if crashed, err := grip.MarkerExists("/var/lib/app/running"); err != nil {
+//		log.Fatal(err)
+//	} else if crashed {
+//		runRecovery()
+//	}
+//	if err := start(); err != nil {
+//		log.Fatal(err)
+//	}
+//	grip.Trap(grip.Exit(ch, os.Stderr, stopHTTP, closeDB, clean), syscall.SIGTERM)
+//
+// clean belongs last in the sequence, so the shutdown only counts as clean
+// once everything before it has run. Both ExitHandlers sync the marker's
+// directory so the marker's state survives a crash.
+func Marker(path string) (start, clean ExitHandler) {
+	start = func() error {
+		content := fmt.Sprintf("pid %d started %s\n", os.Getpid(), time.Now().Format(time.RFC3339))
+		if err := writeSync(path, []byte(content)); err != nil {
+			return err
+		}
+		return SyncDir(filepath.Dir(path))()
+	}
+	clean = func() error {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return SyncDir(filepath.Dir(path))()
+	}
+	return start, clean
+}
+
+// MarkerExists reports whether the marker file of Marker exists at path,
+// meaning the previous run did not shut down cleanly. Call it before start.
+func MarkerExists(path string) (bool, error) {
+	_, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// writeSync writes data to the file at path and fsyncs it.
+func writeSync(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o666)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
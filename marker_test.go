@@ -0,0 +1,62 @@
+package grip_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/codycraven/grip"
+)
+
+func TestMarker(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "running")
+	start, clean := grip.Marker(path)
+
+	exists := func() bool {
+		t.Helper()
+		ok, err := grip.MarkerExists(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ok
+	}
+	if exists() {
+		t.Fatal("marker exists before start")
+	}
+	if err := start(); err != nil {
+		t.Fatal(err)
+	}
+	if !exists() {
+		t.Fatal("marker missing after start")
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prefix := fmt.Sprintf("pid %d started ", os.Getpid()); !strings.HasPrefix(string(b), prefix) {
+		t.Errorf("marker contains %q, want it to start with %q", b, prefix)
+	}
+
+	// A second start, as after a crash, overwrites the marker.
+	if err := start(); err != nil {
+		t.Fatal(err)
+	}
+	if err := clean(); err != nil {
+		t.Fatal(err)
+	}
+	if exists() {
+		t.Error("marker exists after clean")
+	}
+	if err := clean(); err != nil {
+		t.Errorf("clean() without a marker = %v, want nil", err)
+	}
+}
+
+func TestMarkerStartError(t *testing.T) {
+	start, _ := grip.Marker(filepath.Join(t.TempDir(), "missing", "running"))
+	if err := start(); err == nil {
+		t.Error("start() = nil in a directory that does not exist")
+	}
+}
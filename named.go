@@ -0,0 +1,95 @@
+package grip
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// A NamedExitHandler is an ExitHandler with a name used in output and reports.
+type NamedExitHandler struct {
+	Name string
+	Fn   ExitHandler
+	// Signal, if set, restricts a Handler's ExitHandler to shutdowns started
+	// by that signal, as ForSignal does. Shutdowns not started by a signal,
+	// such as one from Runner, skip it.
+	Signal os.Signal
+}
+
+// label returns the name of the NamedExitHandler at index i, falling back to
+// its index when it has none.
+func (n NamedExitHandler) label(i int) string {
+	if n.Name != "" {
+		return n.Name
+	}
+	return fmt.Sprintf("exit handler %d", i)
+}
+
+// Progress wraps NamedExitHandlers so they write their progress to w, giving
+// operators live feedback during a slow shutdown:
+//
+//	step 1/3: stop accepting
+//	step 1/3 done (1.2ms)
+//	step 2/3: drain queue
+//	step 2/3 done (4.8s)
+//	step 3/3: exit handler 2
+//	step 3/3 done (310ms)
+//
+// An ExitHandler without a name is identified by its index. The returned
+// ExitHandlers can be passed to any of the Exit family:
+//
+//	grip.Exit(ch, os.Stderr, grip.Progress(os.Stderr,
+//		grip.NamedExitHandler{Name: "stop accepting", Fn: stopHTTP},
+//		grip.NamedExitHandler{Name: "drain queue", Fn: drainQueue},
+//		grip.NamedExitHandler{Fn: closeDB},
+//	)...)
+func Progress(w io.Writer, fn ...NamedExitHandler) []ExitHandler {
+	w = orDiscard(w)
+	handlers := make([]ExitHandler, len(fn))
+	for i, n := range fn {
+		step, name, f := i+1, n.label(i), n.Fn
+		handlers[i] = func() error {
+			fmt.Fprintf(w, "step %d/%d: %s\n", step, len(fn), name)
+			start := time.Now()
+			err := f()
+			fmt.Fprintf(w, "step %d/%d done (%s)\n", step, len(fn), time.Since(start))
+			return err
+		}
+	}
+	return handlers
+}
+
+// Label attaches a name to an ExitHandler for output only, a lighter
+// alternative to NamedExitHandler that works with the existing Exit family.
+// When a labeled ExitHandler fails, its line in the error writer of Exit names
+// it:
+//
+//	handler 'db' failed (bit 2): connection reset
+//
+// rather than "added 2 to exit code for error: connection reset". The error
+// itself is passed through unchanged, so errors.Is and errors.As still see it.
+//
+//	grip.Exit(ch, os.Stderr, stopHTTP, grip.Label("db", closeDB))
+func Label(name string, fn ExitHandler) ExitHandler {
+	return func() error {
+		if err := fn(); err != nil {
+			return &labeledError{name: name, err: err}
+		}
+		return nil
+	}
+}
+
+// labeledError is the error of an ExitHandler created by Label.
+type labeledError struct {
+	name string
+	err  error
+}
+
+func (e *labeledError) Error() string {
+	return e.err.Error()
+}
+
+func (e *labeledError) Unwrap() error {
+	return e.err
+}
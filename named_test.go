@@ -0,0 +1,57 @@
+package grip_test
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/codycraven/grip"
+)
+
+func TestProgress(t *testing.T) {
+	var buf strings.Builder
+	handlers := grip.Progress(&buf,
+		grip.NamedExitHandler{Name: "stop accepting", Fn: pass},
+		grip.NamedExitHandler{Name: "drain queue", Fn: fail},
+		grip.NamedExitHandler{Fn: pass},
+	)
+	results, _ := grip.RunIsolated(handlers...)
+	if got := grip.Bitmask(results); got != 2 {
+		t.Errorf("exit code %d, want 2", got)
+	}
+	want := regexp.MustCompile(`^step 1/3: stop accepting
+step 1/3 done \(\S+\)
+step 2/3: drain queue
+step 2/3 done \(\S+\)
+step 3/3: exit handler 2
+step 3/3 done \(\S+\)
+$`)
+	if !want.MatchString(buf.String()) {
+		t.Errorf("progress output:\n%s", buf.String())
+	}
+}
+
+func TestLabel(t *testing.T) {
+	var buf bytes.Buffer
+	ch := make(chan int, 1)
+	reset := errors.New("connection reset")
+	var got error
+	grip.Exit(ch, &buf,
+		func() error { return errors.New("timeout") },
+		grip.Capture(&got, grip.Label("db", func() error { return reset })),
+	)(syscall.SIGTERM)
+	if code := <-ch; code != 3 {
+		t.Errorf("exit code %d, want 3", code)
+	}
+	want := "added 1 to exit code for error: timeout\n" +
+		"handler 'db' failed (bit 2): connection reset\n"
+	if buf.String() != want {
+		t.Errorf("output %q, want %q", buf.String(), want)
+	}
+	if !errors.Is(got, reset) {
+		t.Errorf("labeled error %v does not wrap the ExitHandler's error", got)
+	}
+}
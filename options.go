@@ -0,0 +1,477 @@
+package grip
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// A Handler is a shutdown sequence configured with Options. It is an
+// alternative to composing Trap and Exit by hand:
+//
+//	ch := make(chan int)
+//	grip.New(
+//		grip.WithExitHandlers(stopHTTP, closeDB),
+//		grip.WithDebugLogger(slog.Default()),
+//	).Trap(ch)
+//	os.Exit(<-ch)
+type Handler struct {
+	signals    []os.Signal
+	handlers   []NamedExitHandler
+	errWriter  io.Writer
+	logger     *slog.Logger
+	recover    bool
+	message    string
+	msgWriter  io.Writer
+	encoder    Encoder
+	progress   io.Writer
+	minTime    time.Duration
+	summary    func(code int, results []HandlerResult) string
+	timeout    time.Duration
+	concurrent bool
+	ignore     func(error) bool
+	codes      map[os.Signal]int
+	span       func(name string) func(err error)
+	sink       Sink
+
+	// running is set while the ExitHandlers run, from a signal or Runner.
+	running atomic.Bool
+}
+
+// An Option configures a Handler.
+type Option func(*Handler)
+
+// New creates a Handler. Unless configured otherwise it traps
+// InterruptSignals, writes errors to os.Stderr and logs nothing.
+func New(opts ...Option) *Handler {
+	h := &Handler{
+		signals:   InterruptSignals(),
+		errWriter: os.Stderr,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// WithSignals sets the signals a Handler traps.
+func WithSignals(s ...os.Signal) Option {
+	return func(h *Handler) {
+		h.signals = s
+	}
+}
+
+// WithExitHandlers adds ExitHandlers to the end of a Handler's sequence.
+func WithExitHandlers(fn ...ExitHandler) Option {
+	return func(h *Handler) {
+		for _, f := range fn {
+			h.handlers = append(h.handlers, NamedExitHandler{Fn: f})
+		}
+	}
+}
+
+// WithNamedExitHandlers adds NamedExitHandlers to the end of a Handler's
+// sequence.
+func WithNamedExitHandlers(fn ...NamedExitHandler) Option {
+	return func(h *Handler) {
+		h.handlers = append(h.handlers, fn...)
+	}
+}
+
+// WithProgress writes the progress of a Handler's ExitHandlers to w as
+// described by Progress.
+func WithProgress(w io.Writer) Option {
+	return func(h *Handler) {
+		h.progress = w
+	}
+}
+
+// WithErrorWriter sets the io.Writer a Handler writes ExitHandler errors to.
+func WithErrorWriter(w io.Writer) Option {
+	return func(h *Handler) {
+		h.errWriter = w
+	}
+}
+
+// WithSink sets the Sink a Handler reports ExitHandler failures, dropped
+// signals, ignored errors and WithSummary's line to, in place of its error
+// writer. Without WithSummary, the exit code is reported as the Sink's
+// Summary once all of the ExitHandlers have run:
+//
+//	grip.New(grip.WithSink(grip.SlogSink(slog.Default())), grip.WithExitHandlers(stopHTTP, closeDB))
+func WithSink(sink Sink) Option {
+	return func(h *Handler) {
+		h.sink = sink
+	}
+}
+
+// output returns the Sink the Handler reports to.
+func (h *Handler) output() Sink {
+	if h.sink != nil {
+		return h.sink
+	}
+	return WriterSink(h.errWriter)
+}
+
+// WithMessage writes m and the received signal to w, as Message does, before
+// the Handler runs its ExitHandlers.
+func WithMessage(m string, w io.Writer) Option {
+	return func(h *Handler) {
+		h.message, h.msgWriter = m, w
+	}
+}
+
+// WithEncoder sets the Encoder that turns a Handler's results into its exit
+// code. The default is BitmaskEncoder.
+//
+//	grip.New(grip.WithEncoder(grip.BinaryEncoder{}), grip.WithExitHandlers(closeDB, flushLogs))
+func WithEncoder(e Encoder) Option {
+	return func(h *Handler) {
+		h.encoder = e
+	}
+}
+
+// WithMinShutdownTime holds a Handler's exit code for d after its ExitHandlers
+// have finished, however quickly they do, so asynchronous logs and metrics get
+// a chance to reach remote collectors before the process exits. The delay is
+// measured on DefaultClock.
+//
+// It never extends a hard limit such as Deadline, which exits the process
+// whether or not the exit code has been delivered.
+func WithMinShutdownTime(d time.Duration) Option {
+	return func(h *Handler) {
+		h.minTime = d
+	}
+}
+
+// WithTimeout caps the time a Handler spends running its ExitHandlers, as
+// ExitWithin does, or the time of each of them with WithConcurrent.
+func WithTimeout(d time.Duration) Option {
+	return func(h *Handler) {
+		h.timeout = d
+	}
+}
+
+// WithConcurrent runs all of a Handler's ExitHandlers at once, as
+// ExitConcurrentN does. Each keeps the bit of its position.
+func WithConcurrent() Option {
+	return func(h *Handler) {
+		h.concurrent = true
+	}
+}
+
+// WithSignalExitCodes chooses the base exit code of a Handler by the signal
+// that triggered it, for shell-accurate codes in command line tools:
+//
+//	grip.WithSignalExitCodes(map[os.Signal]int{syscall.SIGINT: 130, syscall.SIGTERM: 143})
+//
+// A clean shutdown exits with the base, and the bits of failed ExitHandlers
+// are ORed onto it. Bits already set in the base cannot be told apart from
+// failures: 130 is 128|2, so with the mapping above a failing second
+// ExitHandler leaves the SIGINT exit code at 130. Signals without an entry
+// use a base of 0.
+func WithSignalExitCodes(codes map[os.Signal]int) Option {
+	return func(h *Handler) {
+		h.codes = make(map[os.Signal]int, len(codes))
+		for s, code := range codes {
+			h.codes[s] = code
+		}
+	}
+}
+
+// WithSpan brackets a Handler's shutdown, and each of its ExitHandlers, with
+// calls to start and to the function it returns, so they can be traced
+// without grip depending on a tracing library. start receives "shutdown" for
+// the whole sequence and each ExitHandler's name, or its index if it has
+// none; the returned function receives the error, nil on success:
+//
+//	grip.WithSpan(func(name string) func(error) {
+//		_, span := tracer.Start(ctx, name)
+//		return func(err error) {
+//			if err != nil {
+//				span.SetStatus(codes.Error, err.Error())
+//			}
+//			span.End()
+//		}
+//	})
+//
+// The shutdown's error is an *ExitError holding the exit code and the errors
+// of the failed ExitHandlers. ExitHandlers skipped because of their Signal get
+// no span.
+func WithSpan(start func(name string) func(err error)) Option {
+	return func(h *Handler) {
+		h.span = start
+	}
+}
+
+// spanned wraps fn in a span named name, as described by WithSpan.
+func spanned(start func(name string) func(err error), name string, fn ExitHandler) ExitHandler {
+	return func() error {
+		end := start(name)
+		err := fn()
+		end(err)
+		return err
+	}
+}
+
+// WithIgnoredErrors treats errors for which match returns true as benign, such
+// as a resource that was already closed: the ExitHandler that returned one
+// passes and its bit stays unset. The error is still written to the error
+// writer, noted as ignored:
+//
+//	grip.WithIgnoredErrors(func(err error) bool { return errors.Is(err, net.ErrClosed) })
+//
+// produces "ignored error from db: use of closed network connection".
+func WithIgnoredErrors(match func(error) bool) Option {
+	return func(h *Handler) {
+		h.ignore = match
+	}
+}
+
+// WithSummary writes a single line produced by summary to the Handler's error
+// writer, or as a note to its Sink, once all of its ExitHandlers have run,
+// recapping the shutdown:
+//
+//	grip.WithSummary(func(code int, results []grip.HandlerResult) string {
+//		var failed []string
+//		for _, r := range results {
+//			if r.Failed() {
+//				failed = append(failed, strconv.Itoa(r.Index))
+//			}
+//		}
+//		return fmt.Sprintf("shutdown complete, code=%d, failures=[%s]", code, strings.Join(failed, ","))
+//	})
+func WithSummary(summary func(code int, results []HandlerResult) string) Option {
+	return func(h *Handler) {
+		h.summary = summary
+	}
+}
+
+// WithDebugLogger logs grip's own lifecycle events at debug level: signals
+// being registered, the trap goroutine starting and stopping, and signals
+// being received. It helps diagnose a SignalHandler that never ran. Without
+// it nothing is logged.
+func WithDebugLogger(l *slog.Logger) Option {
+	return func(h *Handler) {
+		h.logger = l
+	}
+}
+
+// WithRecover isolates each of a Handler's ExitHandlers with Recover, so a
+// panic fails its bit rather than crashing the process.
+func WithRecover() Option {
+	return func(h *Handler) {
+		h.recover = true
+	}
+}
+
+// Trap registers the Handler's signals and, when one is received, runs its
+// ExitHandlers in order and sends the exit code described by Exit to ch.
+func (h *Handler) Trap(ch chan int) *TrapHandle {
+	return trap(h.signalHandler(ch), h.logger, false, h.signals)
+}
+
+// Wait blocks until one of the Handler's signals is received, runs its
+// ExitHandlers and calls ExitFunc with the exit code described by Exit.
+func (h *Handler) Wait() {
+	ch := make(chan int)
+	h.Trap(ch)
+	ExitFunc(<-ch)
+}
+
+// Signals returns the signals the Handler traps.
+func (h *Handler) Signals() []os.Signal {
+	return append([]os.Signal(nil), h.signals...)
+}
+
+// HandlerNames returns the names of the Handler's ExitHandlers in order, with
+// unnamed ones identified by their index as in Progress. It allows validating
+// the configuration at startup:
+//
+//	if n := len(h.HandlerNames()); n > 8 {
+//		log.Fatalf("%d exit handlers do not fit in an 8-bit exit code", n)
+//	}
+func (h *Handler) HandlerNames() []string {
+	names := make([]string, len(h.handlers))
+	for i, n := range h.handlers {
+		names[i] = n.label(i)
+	}
+	return names
+}
+
+// Timeout returns the timeout set by WithTimeout, or 0 if there is none.
+func (h *Handler) Timeout() time.Duration {
+	return h.timeout
+}
+
+// Runner returns the Handler's shutdown sequence as a function, so triggers
+// other than a signal, such as an admin endpoint, run exactly the same
+// sequence:
+//
+//	run := h.Runner()
+//	h.Trap(ch)
+//	http.HandleFunc("POST /admin/shutdown", func(w http.ResponseWriter, r *http.Request) {
+//		code, err := run(r.Context())
+//		if err != nil {
+//			http.Error(w, err.Error(), http.StatusConflict)
+//			return
+//		}
+//		ch <- code
+//	})
+//
+// The function runs the ExitHandlers and returns the exit code without
+// delivering it anywhere. Only one sequence runs at a time, whether started by
+// a signal or by the function: while one is running the function returns
+// ErrShutdownInProgress, and a signal is dropped as described by Exit. If ctx
+// is done before the ExitHandlers have finished, the function returns ctx's
+// error while they keep running.
+func (h *Handler) Runner() func(ctx context.Context) (int, error) {
+	return func(ctx context.Context) (int, error) {
+		if !h.running.CompareAndSwap(false, true) {
+			return 0, ErrShutdownInProgress
+		}
+		done := make(chan int, 1)
+		go func() {
+			defer h.running.Store(false)
+			done <- h.run(nil)
+		}()
+		select {
+		case code := <-done:
+			return code, nil
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+}
+
+// signalHandler returns the SignalHandler that runs the Handler's ExitHandlers
+// and sends the exit code to ch.
+func (h *Handler) signalHandler(ch chan int) SignalHandler {
+	fn := exclusiveOn(&h.running, h.output(), func(s os.Signal) {
+		code := h.run(s)
+		if h.minTime > 0 {
+			sleep(h.minTime)
+		}
+		ch <- code
+	})
+	if h.message != "" {
+		fn = Message(h.message, h.msgWriter, fn)
+	}
+	return fn
+}
+
+// run runs the Handler's ExitHandlers for s and returns the exit code.
+func (h *Handler) run(s os.Signal) int {
+	end := func(error) {}
+	if h.span != nil {
+		end = h.span("shutdown")
+	}
+	var encoder Encoder = BitmaskEncoder{}
+	sink := h.output()
+	report := sinkReporter(sink)
+	if h.encoder != nil {
+		encoder, report = h.encoder, indexReporter(sink)
+	}
+	var results []HandlerResult
+	switch fn := h.exitHandlers(s); {
+	case h.concurrent:
+		if h.timeout > 0 {
+			for i, f := range fn {
+				fn[i] = Timeout(h.timeout, f)
+			}
+		}
+		results = runConcurrent(len(fn), fn)
+		for _, r := range results {
+			if r.Failed() {
+				report(r)
+			}
+		}
+	case h.timeout > 0:
+		results = runWithin(h.timeout, fn, report)
+	default:
+		results = runExitHandlers(fn, report)
+	}
+	code := encoder.Encode(results) | h.codes[s]
+	err := exitError(results)
+	if e, ok := err.(*ExitError); ok {
+		// The span reports the exit code the process exits with.
+		e.Code = code
+	}
+	end(err)
+	switch {
+	case h.summary != nil:
+		sink.Note(h.summary(code, results))
+	case h.sink != nil:
+		sink.Summary(code)
+	}
+	return code
+}
+
+// exitHandlers returns the Handler's ExitHandlers with its options applied,
+// for a shutdown started by s.
+func (h *Handler) exitHandlers(s os.Signal) []ExitHandler {
+	named := make([]NamedExitHandler, len(h.handlers))
+	for i, n := range h.handlers {
+		if h.recover {
+			n.Fn = Recover(n.Fn)
+		}
+		if h.ignore != nil {
+			n.Fn = h.ignoreErrors(n.label(i), n.Fn)
+		}
+		if h.span != nil {
+			n.Fn = spanned(h.span, n.label(i), n.Fn)
+		}
+		if n.Signal != nil {
+			n.Fn = onlyFor(n.Signal, s, n.Fn)
+		}
+		named[i] = n
+	}
+	if h.progress != nil {
+		return Progress(h.progress, named...)
+	}
+	fn := make([]ExitHandler, len(named))
+	for i, n := range named {
+		fn[i] = n.Fn
+	}
+	return fn
+}
+
+// ignoreErrors wraps fn so that errors matched by WithIgnoredErrors are
+// written to the error writer and dropped.
+func (h *Handler) ignoreErrors(name string, fn ExitHandler) ExitHandler {
+	return func() error {
+		err := fn()
+		if err != nil && h.ignore(err) {
+			h.output().Note(fmt.Sprintf("ignored error from %s: %s", name, err))
+			return nil
+		}
+		return err
+	}
+}
+
+// Default traps InterruptSignals, writes "shutting down" to os.Stderr when one
+// is received, runs the ExitHandlers in order and exits with the exit code
+// described by Exit. It blocks until then, so it is typically the last call in
+// main once everything has been started in the background:
+//
+//	go server.ListenAndServe()
+//	grip.Default(grip.Shutdownable(ctx, server), closeDB)
+//
+// Default is shorthand for
+//
+//	grip.New(
+//		grip.WithMessage("shutting down", os.Stderr),
+//		grip.WithExitHandlers(fn...),
+//	).Wait()
+//
+// which can be used directly to customize any part of it.
+func Default(fn ...ExitHandler) {
+	New(
+		WithMessage("shutting down", os.Stderr),
+		WithExitHandlers(fn...),
+	).Wait()
+}
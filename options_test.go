@@ -0,0 +1,315 @@
+//go:build !js
+
+package grip_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/codycraven/grip"
+	"github.com/codycraven/grip/griptest"
+)
+
+func TestWithDebugLogger(t *testing.T) {
+	var buf syncBuffer
+	l := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	h := grip.New(grip.WithSignals(syscall.SIGHUP), grip.WithDebugLogger(l), grip.WithErrorWriter(nil))
+	ch := make(chan int, 1)
+	th := h.Trap(ch)
+	t.Cleanup(th.Stop)
+	th.Trigger(syscall.SIGHUP)
+	<-ch
+	<-th.Done()
+	for _, event := range []string{"grip: signals registered", "grip: trap goroutine started", "grip: signal received"} {
+		if !strings.Contains(buf.String(), event) {
+			t.Errorf("log does not contain %q:\n%s", event, buf.String())
+		}
+	}
+}
+
+// sendUntil sends sig every 10ms until an exit code is received on codes,
+// failing t after a second. A one-shot trap stays registered after it fired,
+// so an earlier test may have left sig registered and WaitRegistered cannot
+// tell when the trap under test is.
+func sendUntil(t *testing.T, sig os.Signal, codes <-chan int) int {
+	t.Helper()
+	deadline := time.After(time.Second)
+	for {
+		griptest.Send(sig)
+		select {
+		case code := <-codes:
+			return code
+		case <-time.After(10 * time.Millisecond):
+		case <-deadline:
+			t.Fatalf("no exit code after sending %s", sig)
+		}
+	}
+}
+
+func TestDefault(t *testing.T) {
+	t.Cleanup(grip.Snapshot())
+	exits := griptest.CaptureExit(t)
+	go grip.Default(pass, fail)
+	if code := sendUntil(t, syscall.SIGTERM, exits); code != 2 {
+		t.Errorf("exit code %d, want 2", code)
+	}
+}
+
+func TestWithMinShutdownTime(t *testing.T) {
+	clock := griptest.NewFakeClock(t)
+	h := grip.New(
+		grip.WithSignals(syscall.SIGHUP),
+		grip.WithErrorWriter(nil),
+		grip.WithExitHandlers(pass),
+		grip.WithMinShutdownTime(5*time.Second),
+	)
+	ch := make(chan int, 1)
+	th := h.Trap(ch)
+	t.Cleanup(th.Stop)
+	th.Trigger(syscall.SIGHUP)
+
+	deadline := time.Now().Add(time.Second)
+	for clock.Pending() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("the Handler did not start waiting")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	clock.Advance(4 * time.Second)
+	select {
+	case code := <-ch:
+		t.Fatalf("exit code %d delivered before the minimum shutdown time", code)
+	case <-time.After(10 * time.Millisecond):
+	}
+	clock.Advance(time.Second)
+	select {
+	case <-ch:
+	case <-time.After(time.Second):
+		t.Fatal("exit code not delivered after the minimum shutdown time")
+	}
+}
+
+func TestWithSummary(t *testing.T) {
+	var (
+		gotCode    int
+		gotResults []grip.HandlerResult
+	)
+	var buf bytes.Buffer
+	run := grip.New(
+		grip.WithErrorWriter(&buf),
+		grip.WithExitHandlers(pass, fail, pass),
+		grip.WithSummary(func(code int, results []grip.HandlerResult) string {
+			gotCode, gotResults = code, results
+			return "shutdown complete"
+		}),
+	).Runner()
+	code, err := run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotCode != code || code != 2 {
+		t.Errorf("summary got code %d, want %d", gotCode, code)
+	}
+	if len(gotResults) != 3 || gotResults[0].Failed() || !gotResults[1].Failed() || gotResults[2].Failed() {
+		t.Errorf("summary got results %+v", gotResults)
+	}
+	if !strings.HasSuffix(buf.String(), "shutdown complete\n") {
+		t.Errorf("output %q does not end with the summary", buf.String())
+	}
+}
+
+func TestHandlerAccessors(t *testing.T) {
+	h := grip.New(
+		grip.WithSignals(syscall.SIGTERM, syscall.SIGINT),
+		grip.WithTimeout(5*time.Second),
+		grip.WithExitHandlers(pass),
+		grip.WithNamedExitHandlers(grip.NamedExitHandler{Name: "db", Fn: pass}),
+	)
+	signals := h.Signals()
+	if !slices.Equal(signals, []os.Signal{syscall.SIGTERM, syscall.SIGINT}) {
+		t.Errorf("Signals() = %v, want [SIGTERM SIGINT]", signals)
+	}
+	signals[0] = syscall.SIGHUP
+	if h.Signals()[0] != syscall.SIGTERM {
+		t.Error("Signals() shares its slice with the Handler")
+	}
+	if got := h.HandlerNames(); !slices.Equal(got, []string{"exit handler 0", "db"}) {
+		t.Errorf("HandlerNames() = %q, want [exit handler 0, db]", got)
+	}
+	if got := h.Timeout(); got != 5*time.Second {
+		t.Errorf("Timeout() = %v, want 5s", got)
+	}
+
+	h = grip.New()
+	if !slices.Equal(h.Signals(), grip.InterruptSignals()) || h.Timeout() != 0 || len(h.HandlerNames()) != 0 {
+		t.Errorf("defaults: Signals() = %v, Timeout() = %v, HandlerNames() = %q", h.Signals(), h.Timeout(), h.HandlerNames())
+	}
+}
+
+func TestWithIgnoredErrors(t *testing.T) {
+	var buf bytes.Buffer
+	h := grip.New(
+		grip.WithErrorWriter(&buf),
+		grip.WithIgnoredErrors(func(err error) bool { return errors.Is(err, net.ErrClosed) }),
+		grip.WithNamedExitHandlers(
+			grip.NamedExitHandler{Name: "db", Fn: func() error { return fmt.Errorf("close: %w", net.ErrClosed) }},
+			grip.NamedExitHandler{Name: "cache", Fn: fail},
+		),
+	)
+	code, err := h.Runner()(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code != 2 {
+		t.Errorf("exit code %d, want only the unmatched error's bit, 2", code)
+	}
+	if out := buf.String(); !strings.HasPrefix(out, "ignored error from db: close: use of closed network connection\n") ||
+		!strings.Contains(out, "failed") {
+		t.Errorf("output %q, want the ignored error noted and the other reported", out)
+	}
+}
+
+func TestRunner(t *testing.T) {
+	var buf syncBuffer
+	var runs atomic.Int32
+	started, release := make(chan struct{}), make(chan struct{})
+	h := grip.New(
+		grip.WithSignals(syscall.SIGTERM),
+		grip.WithErrorWriter(&buf),
+		grip.WithExitHandlers(func() error {
+			if runs.Add(1) == 1 {
+				close(started)
+			}
+			<-release
+			return nil
+		}),
+	)
+	run := h.Runner()
+	ch := make(chan int, 1)
+	trap := h.Trap(ch)
+	t.Cleanup(trap.Stop)
+
+	type result struct {
+		code int
+		err  error
+	}
+	first := make(chan result, 1)
+	go func() {
+		code, err := run(context.Background())
+		first <- result{code, err}
+	}()
+	<-started
+
+	// Neither a signal nor another call starts a second sequence.
+	trap.Trigger(syscall.SIGTERM)
+	if _, err := run(context.Background()); !errors.Is(err, grip.ErrShutdownInProgress) {
+		t.Errorf("concurrent run = %v, want ErrShutdownInProgress", err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for !strings.Contains(buf.String(), "ignored terminated: shutdown already in progress") {
+		if time.Now().After(deadline) {
+			t.Fatalf("output %q does not note the dropped signal", buf.String())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	if r := <-first; r.code != 0 || r.err != nil {
+		t.Errorf("run = %d, %v, want 0, nil", r.code, r.err)
+	}
+	select {
+	case code := <-ch:
+		t.Errorf("signal delivered exit code %d during the run", code)
+	default:
+	}
+	if n := runs.Load(); n != 1 {
+		t.Errorf("ExitHandler ran %d times, want 1", n)
+	}
+}
+
+func TestRunnerContext(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	h := grip.New(grip.WithExitHandlers(func() error {
+		<-release
+		return nil
+	}))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := h.Runner()(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("run = %v, want the context's error", err)
+	}
+}
+
+func TestWithSpan(t *testing.T) {
+	var events []string
+	var shutdownErr error
+	h := grip.New(
+		grip.WithErrorWriter(nil),
+		grip.WithSpan(func(name string) func(error) {
+			events = append(events, "start "+name)
+			return func(err error) {
+				events = append(events, fmt.Sprintf("end %s: %v", name, err))
+				if name == "shutdown" {
+					shutdownErr = err
+				}
+			}
+		}),
+		grip.WithNamedExitHandlers(
+			grip.NamedExitHandler{Name: "http", Fn: pass},
+			grip.NamedExitHandler{Name: "reload", Signal: syscall.SIGHUP, Fn: pass},
+			grip.NamedExitHandler{Fn: fail},
+		),
+	)
+	if _, err := h.Runner()(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"start shutdown",
+		"start http",
+		"end http: <nil>",
+		"start exit handler 2",
+		"end exit handler 2: failed",
+		"end shutdown: exit code 4: failed",
+	}
+	if !slices.Equal(events, want) {
+		t.Errorf("spans\n%s\nwant\n%s", strings.Join(events, "\n"), strings.Join(want, "\n"))
+	}
+	var exitErr *grip.ExitError
+	if !errors.As(shutdownErr, &exitErr) {
+		t.Errorf("shutdown span ended with %T, want *grip.ExitError", shutdownErr)
+	}
+}
+
+func TestWithSpanEncodedCode(t *testing.T) {
+	var shutdownErr error
+	h := grip.New(
+		grip.WithErrorWriter(nil),
+		grip.WithEncoder(grip.CountEncoder{}),
+		grip.WithSpan(func(name string) func(error) {
+			return func(err error) {
+				if name == "shutdown" {
+					shutdownErr = err
+				}
+			}
+		}),
+		grip.WithExitHandlers(fail, fail),
+	)
+	if _, err := h.Runner()(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var exitErr *grip.ExitError
+	if !errors.As(shutdownErr, &exitErr) || exitErr.Code != 2 {
+		t.Errorf("shutdown span ended with %v, want exit code 2 from the encoder", shutdownErr)
+	}
+}
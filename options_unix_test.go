@@ -0,0 +1,43 @@
+//go:build unix
+
+package grip_test
+
+import (
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/codycraven/grip"
+)
+
+func TestWithSignalExitCodes(t *testing.T) {
+	codes := map[os.Signal]int{syscall.SIGINT: 130, syscall.SIGTERM: 143}
+	tests := []struct {
+		name string
+		sig  os.Signal
+		fn   []grip.ExitHandler
+		want int
+	}{
+		{"clean SIGINT", syscall.SIGINT, []grip.ExitHandler{pass, pass}, 130},
+		{"clean SIGTERM", syscall.SIGTERM, []grip.ExitHandler{pass, pass}, 143},
+		{"failing SIGINT", syscall.SIGINT, []grip.ExitHandler{fail, pass}, 131},
+		{"failing SIGTERM", syscall.SIGTERM, []grip.ExitHandler{pass, pass, pass, pass, fail}, 159},
+		{"signal without an entry", syscall.SIGHUP, []grip.ExitHandler{pass, fail}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ch := make(chan int, 1)
+			h := grip.New(
+				grip.WithSignals(tt.sig),
+				grip.WithErrorWriter(nil),
+				grip.WithSignalExitCodes(codes),
+				grip.WithExitHandlers(tt.fn...),
+			).Trap(ch)
+			t.Cleanup(h.Stop)
+			h.Trigger(tt.sig)
+			if got := <-ch; got != tt.want {
+				t.Errorf("exit code %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
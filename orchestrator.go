@@ -0,0 +1,96 @@
+package grip
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// An Orchestrator ties a shutdown sequence and a reload action to the signals
+// conventionally used for them: InterruptSignals shut down and SIGHUP reloads.
+//
+//	os.Exit(grip.NewOrchestrator(os.Stderr).
+//		OnShutdown(grip.Shutdownable(ctx, server), closeDB).
+//		OnReload(loadConfig).
+//		Run())
+//
+// Each action can also be called directly, for example from tests or an admin
+// endpoint, with Shutdown and Reload.
+type Orchestrator struct {
+	errWriter io.Writer
+
+	mu       sync.Mutex
+	shutdown []ExitHandler
+	reload   ExitHandler
+}
+
+// NewOrchestrator creates an Orchestrator that writes failures to errWriter.
+func NewOrchestrator(errWriter io.Writer) *Orchestrator {
+	return &Orchestrator{errWriter: errWriter}
+}
+
+// OnShutdown adds ExitHandlers to the end of the shutdown sequence.
+func (o *Orchestrator) OnShutdown(fn ...ExitHandler) *Orchestrator {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.shutdown = append(o.shutdown, fn...)
+	return o
+}
+
+// OnReload sets the action run for each SIGHUP, replacing any previous one.
+func (o *Orchestrator) OnReload(fn ExitHandler) *Orchestrator {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.reload = fn
+	return o
+}
+
+// Shutdown runs the shutdown sequence and returns the exit code described by
+// Exit, writing failures to the error writer.
+func (o *Orchestrator) Shutdown() int {
+	o.mu.Lock()
+	fn := o.shutdown[:len(o.shutdown):len(o.shutdown)]
+	o.mu.Unlock()
+	return exitCode(o.errWriter, fn)
+}
+
+// Reload runs the reload action, if any, writing its error to the error
+// writer as well as returning it. It can be called any number of times.
+func (o *Orchestrator) Reload() error {
+	o.mu.Lock()
+	fn := o.reload
+	o.mu.Unlock()
+	if fn == nil {
+		return nil
+	}
+	err := fn()
+	if err != nil {
+		fmt.Fprintf(orDiscard(o.errWriter), "reload failed: %s\n", err)
+	}
+	return err
+}
+
+// Run traps InterruptSignals and SIGHUP, where available, and blocks until
+// shutdown. Each SIGHUP runs Reload and leaves the process running; the first
+// shutdown signal runs Shutdown and Run returns its exit code, to be passed to
+// os.Exit. The signals are no longer trapped once Run returns.
+//
+// Signals are handled serially, so a SIGHUP received during a reload is
+// handled once the reload has finished.
+func (o *Orchestrator) Run() int {
+	ch := make(chan int)
+	shutdown := exclusive(WriterSink(o.errWriter), func(_ os.Signal) {
+		ch <- o.Shutdown()
+	})
+	r := Router()
+	for _, s := range InterruptSignals() {
+		r.On(s, shutdown)
+	}
+	if SIGHUP != nil {
+		r.On(SIGHUP, func(_ os.Signal) { o.Reload() })
+	}
+	t := r.Trap()
+	defer t.Stop()
+	return <-ch
+}
@@ -0,0 +1,62 @@
+//go:build unix
+
+package grip_test
+
+import (
+	"errors"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/codycraven/grip"
+	"github.com/codycraven/grip/griptest"
+)
+
+func TestOrchestrator(t *testing.T) {
+	var buf syncBuffer
+	var reloads, shutdowns atomic.Int32
+	o := grip.NewOrchestrator(&buf).
+		OnShutdown(pass, func() error {
+			shutdowns.Add(1)
+			return errors.New("db: close failed")
+		}).
+		OnReload(func() error {
+			if reloads.Add(1) == 1 {
+				return errors.New("bad config")
+			}
+			return nil
+		})
+	codes := make(chan int, 1)
+	go func() { codes <- o.Run() }()
+	griptest.WaitRegistered(t, syscall.SIGHUP)
+
+	// Send until the Orchestrator's own registration has seen the signal, in
+	// case an earlier test left another registered.
+	for reloads.Load() < 2 {
+		griptest.Send(syscall.SIGHUP)
+		time.Sleep(time.Millisecond)
+	}
+	if shutdowns.Load() != 0 {
+		t.Fatal("SIGHUP ran the shutdown sequence")
+	}
+	code := sendUntil(t, syscall.SIGTERM, codes)
+	if code != 2 {
+		t.Errorf("exit code %d, want 2", code)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "reload failed: bad config\n") || !strings.Contains(out, "db: close failed") {
+		t.Errorf("output %q does not report both failures", out)
+	}
+}
+
+func TestOrchestratorDirect(t *testing.T) {
+	o := grip.NewOrchestrator(nil)
+	if err := o.Reload(); err != nil {
+		t.Errorf("Reload() without an action = %v, want nil", err)
+	}
+	if code := o.OnShutdown(fail, pass, fail).Shutdown(); code != 5 {
+		t.Errorf("Shutdown() = %d, want 5", code)
+	}
+}
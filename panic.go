@@ -0,0 +1,84 @@
+package grip
+
+import (
+	"fmt"
+	"io"
+	"runtime/debug"
+)
+
+// A PanicError is returned in place of an ExitHandler's error when the
+// ExitHandler panicked and was isolated with Recover.
+type PanicError struct {
+	// Value is the value passed to panic.
+	Value any
+	// Stack is the stack trace of the goroutine at the time of the panic.
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Unwrap returns Value if it is an error, so errors.Is and errors.As see
+// through a panic(err).
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// Recover creates an ExitHandler that turns a panic in fn into a *PanicError,
+// so one misbehaving ExitHandler fails its bit instead of crashing the process
+// before the remaining ExitHandlers have run.
+//
+//	grip.Exit(ch, os.Stderr, grip.Recover(closeDB), grip.Recover(flushLogs))
+func Recover(fn ExitHandler) ExitHandler {
+	return func() (err error) {
+		defer func() {
+			if v := recover(); v != nil {
+				err = &PanicError{Value: v, Stack: debug.Stack()}
+			}
+		}()
+		return fn()
+	}
+}
+
+// RunIsolated calls each ExitHandler in order with Recover and returns their
+// results along with an *ExitError holding the errors of the failed
+// ExitHandlers, or nil if none failed. A panic appears in both as a
+// *PanicError carrying the recovered value and its stack trace:
+//
+//	results, err := grip.RunIsolated(closeDB, flushLogs)
+//	var pe *grip.PanicError
+//	if errors.As(err, &pe) {
+//		log.Printf("shutdown panicked: %v\n%s", pe.Value, pe.Stack)
+//	}
+func RunIsolated(fn ...ExitHandler) ([]HandlerResult, error) {
+	isolated := make([]ExitHandler, len(fn))
+	for i, f := range fn {
+		isolated[i] = Recover(f)
+	}
+	results := runExitHandlers(isolated, func(HandlerResult) {})
+	return results, exitError(results)
+}
+
+// Guard returns a function to defer at the top of main, a last resort
+// covering the whole main flow rather than a single ExitHandler. If main
+// panics, including during shutdown, it recovers the panic, writes the value
+// and stack trace to w and calls ExitFunc with code:
+//
+//	func main() {
+//		defer grip.Guard(os.Stderr, 70)()
+//		...
+//	}
+//
+// Only panics in the goroutine running main can be recovered this way. When
+// main does not panic, the function does nothing.
+func Guard(w io.Writer, code int) func() {
+	w = orDiscard(w)
+	return func() {
+		if v := recover(); v != nil {
+			fmt.Fprintf(w, "panic: %v\n\n%s", v, debug.Stack())
+			ExitFunc(code)
+		}
+	}
+}
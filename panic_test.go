@@ -0,0 +1,75 @@
+package grip_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/codycraven/grip"
+	"github.com/codycraven/grip/griptest"
+)
+
+func TestRunIsolated(t *testing.T) {
+	errBoom := errors.New("boom")
+	results, err := grip.RunIsolated(
+		func() error { panic("explode") },
+		func() error { return nil },
+		func() error { panic(errBoom) },
+	)
+	if len(results) != 3 {
+		t.Fatalf("%d results, want 3", len(results))
+	}
+	if !results[0].Failed() || results[1].Failed() || !results[2].Failed() {
+		t.Errorf("failures %v %v %v, want true false true", results[0].Failed(), results[1].Failed(), results[2].Failed())
+	}
+
+	var pe *grip.PanicError
+	if !errors.As(results[0].Err, &pe) {
+		t.Fatalf("result error %v is not a *PanicError", results[0].Err)
+	}
+	if pe.Value != "explode" {
+		t.Errorf("panic value %v, want explode", pe.Value)
+	}
+	if !strings.Contains(string(pe.Stack), "TestRunIsolated") {
+		t.Errorf("stack trace does not include the panicking function:\n%s", pe.Stack)
+	}
+
+	if !errors.As(err, &pe) {
+		t.Errorf("joined error %v does not contain a *PanicError", err)
+	}
+	if !errors.Is(err, errBoom) {
+		t.Errorf("joined error %v does not wrap the panicked error", err)
+	}
+}
+
+func TestGuard(t *testing.T) {
+	exits := griptest.CaptureExit(t)
+	var buf bytes.Buffer
+	func() {
+		defer grip.Guard(&buf, 70)()
+		panic("nil database")
+	}()
+	if code := <-exits; code != 70 {
+		t.Errorf("exit code %d, want 70", code)
+	}
+	if out := buf.String(); !strings.HasPrefix(out, "panic: nil database\n\n") || !strings.Contains(out, "TestGuard") {
+		t.Errorf("output %q, want the panic value and its stack", out)
+	}
+}
+
+func TestGuardWithoutPanic(t *testing.T) {
+	exits := griptest.CaptureExit(t)
+	var buf bytes.Buffer
+	func() {
+		defer grip.Guard(&buf, 70)()
+	}()
+	select {
+	case code := <-exits:
+		t.Errorf("exit with %d without a panic", code)
+	default:
+	}
+	if buf.Len() != 0 {
+		t.Errorf("unexpected output %q", buf.String())
+	}
+}
@@ -0,0 +1,39 @@
+package grip
+
+// A PlanStep describes one ExitHandler of a Handler's shutdown, as returned by
+// Plan.
+type PlanStep struct {
+	// Index is the position of the ExitHandler, which sets bit 1<<Index.
+	Index int
+	// Name is the ExitHandler's name, or its index if it has none.
+	Name string
+	// Signal is the signal the ExitHandler is restricted to, or empty if it
+	// runs for every signal.
+	Signal string
+	// Stage orders the ExitHandlers: each stage starts once the previous one
+	// has finished, and the ExitHandlers of a stage run concurrently.
+	Stage int
+}
+
+// Plan describes the shutdown h would run, without running anything, for
+// documentation, diagrams or checks in tests:
+//
+//	for _, step := range grip.Plan(h) {
+//		fmt.Printf("%d. %s (bit %d)\n", step.Stage+1, step.Name, 1<<step.Index)
+//	}
+//
+// ExitHandlers run one per stage unless h was configured with WithConcurrent,
+// which puts all of them in stage 0.
+func Plan(h *Handler) []PlanStep {
+	steps := make([]PlanStep, len(h.handlers))
+	for i, n := range h.handlers {
+		steps[i] = PlanStep{Index: i, Name: n.label(i), Stage: i}
+		if n.Signal != nil {
+			steps[i].Signal = n.Signal.String()
+		}
+		if h.concurrent {
+			steps[i].Stage = 0
+		}
+	}
+	return steps
+}
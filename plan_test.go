@@ -0,0 +1,50 @@
+//go:build !js
+
+package grip_test
+
+import (
+	"slices"
+	"syscall"
+	"testing"
+
+	"github.com/codycraven/grip"
+)
+
+func TestPlan(t *testing.T) {
+	handlers := grip.WithNamedExitHandlers(
+		grip.NamedExitHandler{Name: "http", Fn: pass},
+		grip.NamedExitHandler{Name: "reload", Signal: syscall.SIGHUP, Fn: pass},
+		grip.NamedExitHandler{Fn: pass},
+	)
+	ran := false
+	tests := []struct {
+		name string
+		opts []grip.Option
+		want []grip.PlanStep
+	}{
+		{"serial", []grip.Option{handlers}, []grip.PlanStep{
+			{Index: 0, Name: "http", Stage: 0},
+			{Index: 1, Name: "reload", Signal: "hangup", Stage: 1},
+			{Index: 2, Name: "exit handler 2", Stage: 2},
+		}},
+		{"concurrent", []grip.Option{handlers, grip.WithConcurrent()}, []grip.PlanStep{
+			{Index: 0, Name: "http", Stage: 0},
+			{Index: 1, Name: "reload", Signal: "hangup", Stage: 0},
+			{Index: 2, Name: "exit handler 2", Stage: 0},
+		}},
+		{"without names", []grip.Option{grip.WithExitHandlers(func() error {
+			ran = true
+			return nil
+		})}, []grip.PlanStep{{Index: 0, Name: "exit handler 0"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := grip.Plan(grip.New(tt.opts...)); !slices.Equal(got, tt.want) {
+				t.Errorf("Plan() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+	if ran {
+		t.Error("Plan ran an ExitHandler")
+	}
+}
@@ -0,0 +1,44 @@
+package grip
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"time"
+)
+
+// HeapProfile creates a SignalHandler that writes a heap profile to a new
+// timestamped file in dir, such as heap-20240102-150405.000.pprof, for each
+// signal it receives. It does not exit, so it pairs with TrapRepeat and a
+// signal not used for shutdown:
+//
+//	grip.TrapRepeat(grip.HeapProfile("/tmp/profiles", os.Stderr), syscall.SIGUSR2)
+//
+// The profile is taken after a garbage collection so it reflects live memory.
+// Errors are written to errWriter, which may be nil.
+func HeapProfile(dir string, errWriter io.Writer) SignalHandler {
+	errWriter = orDiscard(errWriter)
+	return func(s os.Signal) {
+		path := filepath.Join(dir, "heap-"+time.Now().Format("20060102-150405.000")+".pprof")
+		if err := writeHeapProfile(path); err != nil {
+			fmt.Fprintf(errWriter, "heap profile on %s failed: %s\n", s, err)
+		}
+	}
+}
+
+// writeHeapProfile writes a heap profile to a new file at path.
+func writeHeapProfile(path string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o666)
+	if err != nil {
+		return err
+	}
+	runtime.GC()
+	if err := pprof.WriteHeapProfile(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,41 @@
+//go:build unix
+
+package grip_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/codycraven/grip"
+)
+
+func TestHeapProfile(t *testing.T) {
+	dir := t.TempDir()
+	var buf bytes.Buffer
+	grip.HeapProfile(dir, &buf)(syscall.SIGUSR2)
+	if buf.Len() != 0 {
+		t.Fatalf("unexpected output %q", buf.String())
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "heap-*.pprof"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("found profiles %q, want one", files)
+	}
+	if info, err := os.Stat(files[0]); err != nil || info.Size() == 0 {
+		t.Errorf("profile %s is empty or unreadable: %v", files[0], err)
+	}
+}
+
+func TestHeapProfileError(t *testing.T) {
+	var buf bytes.Buffer
+	grip.HeapProfile(filepath.Join(t.TempDir(), "missing"), &buf)(syscall.SIGUSR2)
+	if !strings.HasPrefix(buf.String(), "heap profile on user defined signal 2 failed: ") {
+		t.Errorf("output %q, want the failure reported", buf.String())
+	}
+}
@@ -0,0 +1,48 @@
+//go:build unix
+
+package grip
+
+import (
+	"io"
+	"os"
+	"runtime"
+	"syscall"
+)
+
+// QuitDump creates a SignalHandler that writes a stack dump of every goroutine
+// to an io.Writer and then calls ExitFunc with exit code 3.
+//
+// This mirrors the Unix convention for SIGQUIT and is useful for diagnosing
+// deadlocks in a running process by sending it SIGQUIT:
+//
+//	grip.Trap(grip.QuitDump(os.Stderr), syscall.SIGQUIT)
+func QuitDump(w io.Writer) SignalHandler {
+	return QuitDumpCode(w, 3)
+}
+
+// QuitDumpCode is like QuitDump but calls ExitFunc with the provided exit
+// code.
+func QuitDumpCode(w io.Writer, code int) SignalHandler {
+	w = orDiscard(w)
+	return func(_ os.Signal) {
+		w.Write(goroutineStacks())
+		ExitFunc(code)
+	}
+}
+
+// TrapQuitDump traps SIGQUIT with QuitDump.
+func TrapQuitDump(w io.Writer) {
+	Trap(QuitDump(w), syscall.SIGQUIT)
+}
+
+// goroutineStacks returns the formatted stack traces of all goroutines.
+func goroutineStacks() []byte {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			return buf[:n]
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
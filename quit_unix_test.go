@@ -0,0 +1,33 @@
+//go:build unix
+
+package grip_test
+
+import (
+	"bytes"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/codycraven/grip"
+	"github.com/codycraven/grip/griptest"
+)
+
+func TestQuitDump(t *testing.T) {
+	exits := griptest.CaptureExit(t)
+	var buf bytes.Buffer
+	grip.QuitDump(&buf)(syscall.SIGQUIT)
+	if code := <-exits; code != 3 {
+		t.Errorf("exit code %d, want 3", code)
+	}
+	if !strings.Contains(buf.String(), "TestQuitDump") {
+		t.Errorf("stack dump does not include the calling goroutine:\n%s", buf.String())
+	}
+}
+
+func TestQuitDumpCode(t *testing.T) {
+	exits := griptest.CaptureExit(t)
+	grip.QuitDumpCode(nil, 42)(syscall.SIGQUIT)
+	if code := <-exits; code != 42 {
+		t.Errorf("exit code %d, want 42", code)
+	}
+}
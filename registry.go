@@ -0,0 +1,115 @@
+package grip
+
+import (
+	"log/slog"
+	"os"
+	"slices"
+	"sync"
+)
+
+// registry tracks the signals trapped by every TrapHandle.
+var registry = struct {
+	mu      sync.Mutex
+	handles map[*TrapHandle]registration
+	retrap  *slog.Logger
+}{handles: map[*TrapHandle]registration{}}
+
+// A registration is the set of signals a TrapHandle traps.
+type registration struct {
+	signals []os.Signal
+	// all is set for a TrapHandle trapping every signal, which os/signal
+	// does when Notify is called without signals.
+	all bool
+}
+
+// traps reports whether r includes sig.
+func (r registration) traps(sig os.Signal) bool {
+	return r.all || containsSignal(r.signals, sig)
+}
+
+// WarnOnRetrap makes every later Trap for a signal that is already trapped
+// through this package log a warning to l. It surfaces accidental double
+// registration, typically from several packages each trapping SIGTERM, which
+// leaves every one of their SignalHandlers running on the same signal.
+//
+// Passing nil turns the warnings off again, which is the default.
+func WarnOnRetrap(l *slog.Logger) {
+	registry.mu.Lock()
+	registry.retrap = l
+	registry.mu.Unlock()
+}
+
+// Snapshot records the signals currently trapped through this package and
+// returns a function that restores exactly that state. Restoring stops every
+// TrapHandle created since the snapshot and registers the TrapHandles that
+// existed at the time for the signals they had then, undoing any Stop or
+// Remove in between; those left unchanged keep their registration. It
+// isolates the traps of a plugin or test:
+//
+//	restore := grip.Snapshot()
+//	plugin.Load() // may Trap signals of its own
+//	...
+//	plugin.Unload()
+//	restore()
+//
+// TrapChan does not register signals, so its TrapHandles are not affected.
+func Snapshot() (restore func()) {
+	registry.mu.Lock()
+	saved := make(map[*TrapHandle]registration, len(registry.handles))
+	for t, r := range registry.handles {
+		saved[t] = r
+	}
+	registry.mu.Unlock()
+
+	return func() {
+		registry.mu.Lock()
+		var added []*TrapHandle
+		for t := range registry.handles {
+			if _, ok := saved[t]; !ok {
+				added = append(added, t)
+			}
+		}
+		registry.mu.Unlock()
+
+		for _, t := range added {
+			t.Stop()
+		}
+		for t, r := range saved {
+			t.mu.Lock()
+			// An unchanged TrapHandle keeps its registration untouched.
+			if t.all != r.all || !slices.Equal(t.signals, r.signals) {
+				t.setRegistration(r)
+			}
+			t.mu.Unlock()
+		}
+	}
+}
+
+// register records that t now traps the signals of r, warning about any
+// signal t did not already trap that another TrapHandle does if WarnOnRetrap
+// is enabled.
+func register(t *TrapHandle, r registration) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	if registry.retrap != nil {
+		for _, sig := range r.signals {
+			if registry.handles[t].traps(sig) {
+				continue
+			}
+			traps := 1
+			for other, o := range registry.handles {
+				if other != t && o.traps(sig) {
+					traps++
+				}
+			}
+			if traps > 1 {
+				registry.retrap.Warn("grip: signal trapped more than once", "signal", sig, "traps", traps)
+			}
+		}
+	}
+	if !r.all && len(r.signals) == 0 {
+		delete(registry.handles, t)
+		return
+	}
+	registry.handles[t] = r
+}
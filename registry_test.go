@@ -0,0 +1,56 @@
+//go:build !js
+
+package grip_test
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/codycraven/grip"
+	"github.com/codycraven/grip/griptest"
+)
+
+func TestWarnOnRetrap(t *testing.T) {
+	var buf bytes.Buffer
+	grip.WarnOnRetrap(slog.New(slog.NewTextHandler(&buf, nil)))
+	t.Cleanup(func() { grip.WarnOnRetrap(nil) })
+	noop := func(os.Signal) {}
+
+	a := grip.TrapRepeat(noop, syscall.SIGHUP)
+	t.Cleanup(a.Stop)
+	if buf.Len() != 0 {
+		t.Fatalf("warned about the first trap: %s", buf.String())
+	}
+	b := grip.TrapRepeat(noop, syscall.SIGHUP, syscall.SIGINT)
+	t.Cleanup(b.Stop)
+	out := buf.String()
+	if strings.Count(out, "signal trapped more than once") != 1 || !strings.Contains(out, "traps=2") {
+		t.Errorf("log %q, want one warning about SIGHUP trapped twice", out)
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	fn, got := recv()
+	kept := grip.TrapRepeat(fn, syscall.SIGHUP, syscall.SIGINT)
+	t.Cleanup(kept.Stop)
+
+	restore := grip.Snapshot()
+	extra, extras := recv()
+	added := grip.TrapRepeat(extra, syscall.SIGTERM)
+	t.Cleanup(added.Stop)
+	kept.Remove(syscall.SIGINT)
+	restore()
+
+	griptest.Send(syscall.SIGTERM)
+	none(t, extras)
+	for _, sig := range []os.Signal{syscall.SIGHUP, syscall.SIGINT} {
+		if !griptest.Send(sig) {
+			t.Fatalf("%s not registered after restore", sig)
+		}
+		want(t, got, sig)
+	}
+}
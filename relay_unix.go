@@ -0,0 +1,83 @@
+//go:build unix
+
+package grip
+
+import (
+	"errors"
+	"os"
+	"syscall"
+	"time"
+)
+
+// A Process can be sent signals. *os.Process satisfies it.
+type Process interface {
+	Signal(os.Signal) error
+}
+
+// A RelayStep is one step of RelaySequence: the signal to send and how long
+// to give the processes to exit before moving on to the next step.
+type RelayStep struct {
+	Sig  os.Signal
+	Wait time.Duration
+}
+
+// relayPoll is how often RelaySequence checks whether processes have exited.
+const relayPoll = 50 * time.Millisecond
+
+// Relay creates a SignalHandler that forwards each signal it receives to the
+// processes, for a supervisor running child processes:
+//
+//	grip.TrapRepeat(grip.Relay(cmd.Process), syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+//
+// Processes that have already exited are skipped.
+func Relay(procs ...Process) SignalHandler {
+	return func(s os.Signal) {
+		for _, p := range procs {
+			p.Signal(s)
+		}
+	}
+}
+
+// RelaySequence creates a SignalHandler that escalates through steps for
+// children that ignore gentler signals. For each step it sends the step's
+// signal to every process that is still running and waits up to the step's
+// Wait for them to exit, moving on as soon as they all have:
+//
+//	grip.Trap(grip.RelaySequence([]grip.RelayStep{
+//		{Sig: syscall.SIGTERM, Wait: 10 * time.Second},
+//		{Sig: syscall.SIGINT, Wait: 5 * time.Second},
+//		{Sig: syscall.SIGKILL},
+//	}, cmd.Process), syscall.SIGTERM)
+//
+// The received signal itself is not relayed. A process counts as exited once
+// signalling it fails with os.ErrProcessDone, which for an *os.Process means
+// it has been waited for, so something, typically exec.Cmd.Wait, must be
+// waiting on each child. Waits are measured on DefaultClock. RelaySequence is
+// only available on Unix.
+func RelaySequence(steps []RelayStep, procs ...Process) SignalHandler {
+	return func(_ os.Signal) {
+		running := procs
+		for _, step := range steps {
+			running = signalRunning(running, step.Sig)
+			deadline := DefaultClock.Now().Add(step.Wait)
+			for len(running) > 0 && DefaultClock.Now().Before(deadline) {
+				sleep(min(relayPoll, deadline.Sub(DefaultClock.Now())))
+				running = signalRunning(running, syscall.Signal(0))
+			}
+			if len(running) == 0 {
+				return
+			}
+		}
+	}
+}
+
+// signalRunning sends sig to procs and returns those that have not exited.
+func signalRunning(procs []Process, sig os.Signal) []Process {
+	var running []Process
+	for _, p := range procs {
+		if err := p.Signal(sig); !errors.Is(err, os.ErrProcessDone) {
+			running = append(running, p)
+		}
+	}
+	return running
+}
@@ -0,0 +1,75 @@
+//go:build unix
+
+package grip_test
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/codycraven/grip"
+)
+
+// fakeProcess records the signals it is sent, ignoring the probe signal 0,
+// and exits once it has been sent exitOn.
+type fakeProcess struct {
+	exitOn os.Signal
+	got    []os.Signal
+	exited bool
+}
+
+func (p *fakeProcess) Signal(sig os.Signal) error {
+	if p.exited {
+		return os.ErrProcessDone
+	}
+	if sig != syscall.Signal(0) {
+		p.got = append(p.got, sig)
+	}
+	if sig == p.exitOn {
+		p.exited = true
+	}
+	return nil
+}
+
+func TestRelay(t *testing.T) {
+	p := &fakeProcess{}
+	done := &fakeProcess{exited: true}
+	grip.Relay(p, done)(syscall.SIGHUP)
+	if len(p.got) != 1 || p.got[0] != syscall.SIGHUP {
+		t.Errorf("relayed %v, want [SIGHUP]", p.got)
+	}
+}
+
+func TestRelaySequence(t *testing.T) {
+	stubborn := &fakeProcess{exitOn: syscall.SIGINT}
+	gentle := &fakeProcess{exitOn: syscall.SIGTERM}
+	start := time.Now()
+	grip.RelaySequence([]grip.RelayStep{
+		{Sig: syscall.SIGTERM, Wait: 30 * time.Millisecond},
+		{Sig: syscall.SIGINT, Wait: time.Minute},
+		{Sig: syscall.SIGKILL},
+	}, stubborn, gentle)(syscall.SIGTERM)
+
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("took %v, want to stop as soon as every process exited", elapsed)
+	}
+	want := []os.Signal{syscall.SIGTERM, syscall.SIGINT}
+	if len(stubborn.got) != len(want) || stubborn.got[0] != want[0] || stubborn.got[1] != want[1] {
+		t.Errorf("sent %v to the process exiting on the second step, want %v", stubborn.got, want)
+	}
+	if len(gentle.got) != 1 {
+		t.Errorf("sent %v to the process exiting on the first step, want only SIGTERM", gentle.got)
+	}
+}
+
+func TestRelaySequenceGivesUp(t *testing.T) {
+	deaf := &fakeProcess{}
+	grip.RelaySequence([]grip.RelayStep{
+		{Sig: syscall.SIGTERM, Wait: 10 * time.Millisecond},
+		{Sig: syscall.SIGKILL},
+	}, deaf)(syscall.SIGTERM)
+	if len(deaf.got) != 2 || deaf.got[1] != syscall.SIGKILL {
+		t.Errorf("sent %v, want every step", deaf.got)
+	}
+}
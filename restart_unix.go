@@ -0,0 +1,39 @@
+//go:build unix
+
+package grip
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// Restart creates a SignalHandler that replaces the running process with a
+// fresh copy of the same binary, with the same arguments and environment. It
+// is the classic way to apply configuration that cannot be reloaded live:
+//
+//	grip.Trap(grip.Restart(stopHTTP, closeDB), syscall.SIGHUP)
+//
+// The cleanup ExitHandlers run in order before restarting and the restart
+// happens whether or not they fail. If the restart itself fails, the error is
+// written to os.Stderr and the process keeps running.
+//
+// Restart is only available on Unix, where the process image is replaced in
+// place with syscall.Exec and keeps its process ID. File descriptors opened
+// without close-on-exec, which Go sets by default, remain open in the new
+// process, so listeners or files handed to the process that way must be dealt
+// with by the cleanup ExitHandlers or the new process.
+func Restart(cleanup ...ExitHandler) SignalHandler {
+	return func(_ os.Signal) {
+		for _, f := range cleanup {
+			f()
+		}
+		path, err := os.Executable()
+		if err != nil {
+			path = os.Args[0]
+		}
+		if err := syscall.Exec(path, os.Args, os.Environ()); err != nil {
+			fmt.Fprintf(os.Stderr, "restart failed: %s\n", err)
+		}
+	}
+}
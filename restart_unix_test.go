@@ -0,0 +1,39 @@
+//go:build unix
+
+package grip_test
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/codycraven/grip"
+)
+
+func TestRestart(t *testing.T) {
+	switch os.Getenv("GRIP_TEST_RESTART") {
+	case "child":
+		os.Setenv("GRIP_TEST_RESTART", "restarted")
+		grip.Restart(func() error {
+			fmt.Println("cleanup ran")
+			return nil
+		})(syscall.SIGHUP)
+		os.Exit(1) // only reached if the restart failed
+	case "restarted":
+		fmt.Println("restarted")
+		os.Exit(0)
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestRestart$")
+	cmd.Env = append(os.Environ(), "GRIP_TEST_RESTART=child")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("child failed: %v\n%s", err, out)
+	}
+	if !strings.Contains(string(out), "cleanup ran\nrestarted\n") {
+		t.Errorf("child output %q, want the cleanup to run before the restart", out)
+	}
+}
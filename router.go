@@ -0,0 +1,73 @@
+package grip
+
+import (
+	"os"
+	"sync"
+)
+
+// A SignalRouter dispatches each signal it traps to the SignalHandler
+// registered for it. Create one with Router.
+type SignalRouter struct {
+	mu       sync.Mutex
+	routes   map[os.Signal]SignalHandler
+	signals  []os.Signal
+	fallback SignalHandler
+}
+
+// Router creates an empty SignalRouter. Its methods chain to express a
+// complete signal setup in one statement:
+//
+//	grip.Router().
+//		On(syscall.SIGHUP, func(_ os.Signal) { reload() }).
+//		On(syscall.SIGTERM, shutdown).
+//		On(syscall.SIGINT, shutdown).
+//		On(syscall.SIGUSR1, grip.Stats(os.Stderr, start)).
+//		Default(func(s os.Signal) { log.Printf("ignoring %s", s) }).
+//		Trap(syscall.SIGUSR2)
+func Router() *SignalRouter {
+	return &SignalRouter{routes: make(map[os.Signal]SignalHandler)}
+}
+
+// On routes sig to fn, replacing any SignalHandler previously routed for sig.
+func (r *SignalRouter) On(sig os.Signal, fn SignalHandler) *SignalRouter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.routes[sig]; !ok {
+		r.signals = append(r.signals, sig)
+	}
+	r.routes[sig] = fn
+	return r
+}
+
+// Default sets the SignalHandler for trapped signals that have no route of
+// their own. Without one such signals are ignored.
+func (r *SignalRouter) Default(fn SignalHandler) *SignalRouter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fallback = fn
+	return r
+}
+
+// Trap registers every routed signal, plus extra signals that only the
+// Default SignalHandler handles, and dispatches each received signal as
+// TrapRepeat would: serially, with signals arriving during a call coalesced
+// into one call once it returns.
+func (r *SignalRouter) Trap(extra ...os.Signal) *TrapHandle {
+	r.mu.Lock()
+	signals := append(r.signals[:len(r.signals):len(r.signals)], extra...)
+	r.mu.Unlock()
+	return TrapRepeat(r.dispatch, signals...)
+}
+
+// dispatch calls the SignalHandler routed for s, falling back to Default.
+func (r *SignalRouter) dispatch(s os.Signal) {
+	r.mu.Lock()
+	fn, ok := r.routes[s]
+	if !ok {
+		fn = r.fallback
+	}
+	r.mu.Unlock()
+	if fn != nil {
+		fn(s)
+	}
+}
@@ -0,0 +1,72 @@
+//go:build unix
+
+package grip_test
+
+import (
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/codycraven/grip"
+	"github.com/codycraven/grip/griptest"
+)
+
+func TestRouter(t *testing.T) {
+	reload, reloads := recv()
+	shutdown, shutdowns := recv()
+	fallback, fallbacks := recv()
+	h := grip.Router().
+		On(syscall.SIGHUP, reload).
+		On(syscall.SIGTERM, shutdown).
+		On(syscall.SIGINT, shutdown).
+		Default(fallback).
+		Trap(syscall.SIGUSR2)
+	t.Cleanup(h.Stop)
+
+	for _, sig := range []syscall.Signal{syscall.SIGHUP, syscall.SIGTERM, syscall.SIGINT, syscall.SIGUSR2} {
+		griptest.WaitRegistered(t, sig)
+	}
+	h.Trigger(syscall.SIGHUP)
+	want(t, reloads, syscall.SIGHUP)
+	h.Trigger(syscall.SIGINT)
+	want(t, shutdowns, syscall.SIGINT)
+	h.Trigger(syscall.SIGTERM)
+	want(t, shutdowns, syscall.SIGTERM)
+	h.Trigger(syscall.SIGUSR2)
+	want(t, fallbacks, syscall.SIGUSR2)
+	none(t, reloads)
+	none(t, fallbacks)
+}
+
+func TestRouterOnReplaces(t *testing.T) {
+	first, firsts := recv()
+	second, seconds := recv()
+	h := grip.Router().On(syscall.SIGHUP, first).On(syscall.SIGHUP, second).Trap()
+	t.Cleanup(h.Stop)
+	h.Trigger(syscall.SIGHUP)
+	want(t, seconds, syscall.SIGHUP)
+	none(t, firsts)
+}
+
+func TestRouterWithoutDefault(t *testing.T) {
+	reload, reloads := recv()
+	h := grip.Router().On(syscall.SIGHUP, reload).Trap(syscall.SIGUSR2)
+	t.Cleanup(h.Stop)
+	// SIGUSR2 is ignored without a Default, and the trap goes on to the next
+	// signal. Trigger drops SIGHUP while SIGUSR2 is still pending: retry.
+	h.Trigger(syscall.SIGUSR2)
+	deadline := time.After(time.Second)
+	for {
+		h.Trigger(syscall.SIGHUP)
+		select {
+		case s := <-reloads:
+			if s != syscall.SIGHUP {
+				t.Fatalf("reload received %s", s)
+			}
+			return
+		case <-time.After(10 * time.Millisecond):
+		case <-deadline:
+			t.Fatal("trap stopped after an ignored signal")
+		}
+	}
+}
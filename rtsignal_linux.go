@@ -0,0 +1,34 @@
+//go:build linux
+
+package grip
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// The start of the real-time signal range available to programs. The kernel
+// starts the range at 32, but the C library reserves 32 and 33 for its
+// threading implementation, so like glibc's SIGRTMIN the usable range starts at
+// 34. Package syscall does not define it, nor sigRTMax, which depends on the
+// architecture.
+const sigRTMin = 34
+
+// RTSignal returns the real-time signal SIGRTMIN+n, for programs that use
+// real-time signals to coordinate with each other:
+//
+//	sig, err := grip.RTSignal(2)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	grip.TrapRepeat(onPeerReady, sig)
+//
+// It returns an error if SIGRTMIN+n is above SIGRTMAX, which is 64 on most
+// architectures and 127 on MIPS. RTSignal is only available on Linux.
+func RTSignal(n int) (os.Signal, error) {
+	if n < 0 || n > sigRTMax-sigRTMin {
+		return nil, fmt.Errorf("real-time signal SIGRTMIN+%d out of range 0 to %d", n, sigRTMax-sigRTMin)
+	}
+	return syscall.Signal(sigRTMin + n), nil
+}
@@ -0,0 +1,46 @@
+package grip_test
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/codycraven/grip"
+)
+
+func TestRTSignal(t *testing.T) {
+	sig, err := grip.RTSignal(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sig != syscall.Signal(34) {
+		t.Errorf("RTSignal(0) = %d, want 34", sig)
+	}
+	for _, n := range []int{-1, 1000} {
+		if _, err := grip.RTSignal(n); err == nil {
+			t.Errorf("RTSignal(%d) returned no error", n)
+		}
+	}
+}
+
+func TestTrapRTSignal(t *testing.T) {
+	sig, err := grip.RTSignal(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(chan os.Signal, 1)
+	h := grip.TrapRepeat(func(s os.Signal) { got <- s }, sig)
+	t.Cleanup(h.Stop)
+	if err := syscall.Kill(os.Getpid(), sig.(syscall.Signal)); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case s := <-got:
+		if s != sig {
+			t.Errorf("received %v, want %v", s, sig)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("%v was not delivered", sig)
+	}
+}
@@ -0,0 +1,6 @@
+//go:build linux && !(mips || mipsle || mips64 || mips64le)
+
+package grip
+
+// sigRTMax is SIGRTMAX, the last real-time signal.
+const sigRTMax = 64
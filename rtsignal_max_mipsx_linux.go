@@ -0,0 +1,6 @@
+//go:build linux && (mips || mipsle || mips64 || mips64le)
+
+package grip
+
+// sigRTMax is SIGRTMAX, the last real-time signal. MIPS has 128 signals.
+const sigRTMax = 127
@@ -0,0 +1,128 @@
+package grip
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// RunUntilSignal calls run with a context that is cancelled when one of the
+// provided os.Signals is received, or InterruptSignals if none are provided,
+// and returns an exit code for run's result. It lets main be written as a
+// context-aware function while grip owns the signal plumbing:
+//
+//	func main() {
+//		os.Exit(grip.RunUntilSignal(func(ctx context.Context) error {
+//			return server.Serve(ctx)
+//		}))
+//	}
+//
+// The exit code is 0 if run returns nil, or if it returns the context's error
+// after a signal cancelled it. Any other error is written to os.Stderr and
+// produces 1.
+func RunUntilSignal(run func(ctx context.Context) error, s ...os.Signal) int {
+	if len(s) == 0 {
+		s = InterruptSignals()
+	}
+	ctx, stop := notifyContext(context.Background(), s...)
+	defer stop()
+	err := run(ctx)
+	if err == nil || ctx.Err() != nil && errors.Is(err, ctx.Err()) {
+		return 0
+	}
+	fmt.Fprintf(os.Stderr, "run failed: %s\n", err)
+	return 1
+}
+
+// AfterSignal arranges for fn to be called in its own goroutine once one of
+// the provided os.Signals is received, using context.AfterFunc on a context
+// cancelled by the signal. It returns a stop function with the semantics of
+// the one returned by context.AfterFunc: calling it before a signal arrives
+// prevents fn from running, and it reports whether it did so.
+//
+//	stop := grip.AfterSignal(grip.InterruptSignals(), func() { server.Close() })
+//	defer stop()
+//
+// fn runs at most once. Once a signal has been received, or stop has
+// prevented fn from running, the signals are no longer trapped and further
+// ones get their default behavior.
+func AfterSignal(s []os.Signal, fn func()) (stop func() bool) {
+	ctx, stopNotify := notifyContext(context.Background(), s...)
+	stopAfter := context.AfterFunc(ctx, func() {
+		stopNotify()
+		fn()
+	})
+	return func() bool {
+		stopped := stopAfter()
+		if stopped {
+			stopNotify()
+		}
+		return stopped
+	}
+}
+
+// RunWithSignals combines a signal-cancelled context with the Exit sequence.
+// The returned context stays live until one of the provided os.Signals is
+// received, or InterruptSignals if none are provided. The signal cancels it
+// and starts running the ExitHandlers in order, writing failures to
+// os.Stderr. code blocks until they have finished and returns the exit code
+// described by Exit:
+//
+//	func main() {
+//		ctx, code := grip.RunWithSignals(nil, stopHTTP, closeDB)
+//		for ctx.Err() == nil {
+//			work(ctx)
+//		}
+//		os.Exit(code())
+//	}
+//
+// If code is called before any signal was received, for example because the
+// main loop stopped on its own, it cancels the context and runs the
+// ExitHandlers itself, so a program written this way always shuts down the
+// same way. The ExitHandlers run at most once and code can be called any
+// number of times.
+func RunWithSignals(signals []os.Signal, fn ...ExitHandler) (ctx context.Context, code func() int) {
+	if len(signals) == 0 {
+		signals = InterruptSignals()
+	}
+	ctx, stop := notifyContext(context.Background(), signals...)
+	var (
+		once sync.Once
+		exit int
+	)
+	shutdown := func() {
+		once.Do(func() {
+			stop()
+			exit = exitCode(os.Stderr, fn)
+		})
+	}
+	go func() {
+		<-ctx.Done()
+		shutdown()
+	}()
+	return ctx, func() int {
+		shutdown()
+		return exit
+	}
+}
+
+// Manage owns the whole lifecycle: it blocks until one of the provided
+// os.Signals is received, or InterruptSignals if none are provided, runs the
+// ExitHandlers in order writing failures to w, and calls ExitFunc with the
+// exit code described by Exit:
+//
+//	go server.ListenAndServe()
+//	grip.Manage(nil, os.Stderr, grip.Shutdownable(ctx, server), closeDB)
+//
+// Manage is shorthand for the equivalent New, WithSignals, WithErrorWriter and
+// WithExitHandlers followed by Handler.Wait.
+func Manage(signals []os.Signal, w io.Writer, fn ...ExitHandler) {
+	opts := []Option{WithErrorWriter(w), WithExitHandlers(fn...)}
+	if len(signals) > 0 {
+		opts = append(opts, WithSignals(signals...))
+	}
+	New(opts...).Wait()
+}
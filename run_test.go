@@ -0,0 +1,122 @@
+//go:build !js
+
+package grip_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/codycraven/grip"
+	"github.com/codycraven/grip/griptest"
+)
+
+func TestRunUntilSignal(t *testing.T) {
+	code := grip.RunUntilSignal(func(ctx context.Context) error {
+		griptest.WaitRegistered(t, syscall.SIGTERM)
+		griptest.Send(syscall.SIGTERM)
+		<-ctx.Done()
+		return ctx.Err()
+	}, syscall.SIGTERM)
+	if code != 0 {
+		t.Errorf("exit code %d, want 0", code)
+	}
+}
+
+func TestRunUntilSignalError(t *testing.T) {
+	code := grip.RunUntilSignal(func(ctx context.Context) error {
+		return errors.New("listen: address in use")
+	}, syscall.SIGTERM)
+	if code != 1 {
+		t.Errorf("exit code %d, want 1", code)
+	}
+}
+
+func TestAfterSignal(t *testing.T) {
+	ran := make(chan struct{})
+	stop := grip.AfterSignal([]os.Signal{syscall.SIGHUP}, func() { close(ran) })
+	defer stop()
+	griptest.WaitRegistered(t, syscall.SIGHUP)
+	griptest.Send(syscall.SIGHUP)
+	select {
+	case <-ran:
+	case <-time.After(time.Second):
+		t.Fatal("fn did not run after the signal")
+	}
+	if stop() {
+		t.Error("stop reported preventing fn after it ran")
+	}
+}
+
+func TestAfterSignalStop(t *testing.T) {
+	stop := grip.AfterSignal([]os.Signal{syscall.SIGHUP}, func() { t.Error("fn ran after stop") })
+	if !stop() {
+		t.Error("stop did not report preventing fn")
+	}
+	if griptest.Send(syscall.SIGHUP) {
+		t.Error("SIGHUP is still registered after stop")
+	}
+}
+
+func TestRunWithSignals(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   []grip.ExitHandler
+		want int
+	}{
+		{"clean exit", []grip.ExitHandler{pass, pass}, 0},
+		{"handler failure", []grip.ExitHandler{pass, fail}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, code := grip.RunWithSignals([]os.Signal{syscall.SIGTERM}, tt.fn...)
+			griptest.WaitRegistered(t, syscall.SIGTERM)
+			if ctx.Err() != nil {
+				t.Fatal("context cancelled before any signal")
+			}
+			griptest.Send(syscall.SIGTERM)
+			<-ctx.Done()
+			if got := code(); got != tt.want {
+				t.Errorf("exit code %d, want %d", got, tt.want)
+			}
+			if got := code(); got != tt.want {
+				t.Errorf("second call to code returned %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunWithSignalsWithoutSignal(t *testing.T) {
+	var runs int
+	ctx, code := grip.RunWithSignals([]os.Signal{syscall.SIGTERM}, func() error {
+		runs++
+		return nil
+	})
+	if got := code(); got != 0 {
+		t.Errorf("exit code %d, want 0", got)
+	}
+	if ctx.Err() == nil {
+		t.Error("code did not cancel the context")
+	}
+	code()
+	if runs != 1 {
+		t.Errorf("ExitHandler ran %d times, want 1", runs)
+	}
+}
+
+func TestManage(t *testing.T) {
+	exits := griptest.CaptureExit(t)
+	var buf syncBuffer
+	go grip.Manage([]os.Signal{syscall.SIGTERM}, &buf, pass, fail)
+	code := sendUntil(t, syscall.SIGTERM, exits)
+	if code != 2 {
+		t.Errorf("exit code %d, want 2", code)
+	}
+	if !strings.Contains(buf.String(), "failed") {
+		t.Errorf("output %q does not report the failure", buf.String())
+	}
+}
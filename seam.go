@@ -0,0 +1,48 @@
+package grip
+
+import (
+	"context"
+	"os"
+	"sync"
+
+	"github.com/codycraven/grip/internal/seam"
+)
+
+// osSignal holds the os/signal functions this package calls. They go through
+// internal/seam, which records the registered channels so that griptest can
+// deliver signals to them, and tests can replace them with fakes.
+var osSignal = struct {
+	Notify func(c chan<- os.Signal, sig ...os.Signal)
+	Stop   func(c chan<- os.Signal)
+	Reset  func(sig ...os.Signal)
+	Ignore func(sig ...os.Signal)
+}{
+	Notify: seam.Notify,
+	Stop:   seam.Stop,
+	Reset:  seam.Reset,
+	Ignore: seam.Ignore,
+}
+
+// notifyContext is signal.NotifyContext on top of osSignal.
+func notifyContext(parent context.Context, s ...os.Signal) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+	ch := make(chan os.Signal, 1)
+	osSignal.Notify(ch, s...)
+	stopped := make(chan struct{})
+	go func() {
+		select {
+		case <-ch:
+			cancel()
+		case <-ctx.Done():
+		case <-stopped:
+		}
+	}()
+	var once sync.Once
+	return ctx, func() {
+		once.Do(func() {
+			osSignal.Stop(ch)
+			close(stopped)
+			cancel()
+		})
+	}
+}
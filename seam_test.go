@@ -0,0 +1,121 @@
+//go:build unix
+
+package grip
+
+import (
+	"context"
+	"os"
+	"slices"
+	"syscall"
+	"testing"
+)
+
+// fakeOSSignal replaces osSignal for the duration of the test and records the
+// calls made to it. Nothing reaches os/signal.
+type fakeOSSignal struct {
+	notified map[chan<- os.Signal][]os.Signal
+	stopped  []chan<- os.Signal
+	ignored  []os.Signal
+	// calls lists "notify" and "stop" in the order they were made.
+	calls []string
+}
+
+func newFakeOSSignal(t *testing.T) *fakeOSSignal {
+	f := &fakeOSSignal{notified: map[chan<- os.Signal][]os.Signal{}}
+	orig := osSignal
+	osSignal.Notify = func(c chan<- os.Signal, sig ...os.Signal) {
+		f.notified[c] = append(f.notified[c], sig...)
+		f.calls = append(f.calls, "notify")
+	}
+	osSignal.Stop = func(c chan<- os.Signal) {
+		delete(f.notified, c)
+		f.stopped = append(f.stopped, c)
+		f.calls = append(f.calls, "stop")
+	}
+	osSignal.Ignore = func(sig ...os.Signal) {
+		f.ignored = append(f.ignored, sig...)
+	}
+	t.Cleanup(func() {
+		osSignal = orig
+	})
+	return f
+}
+
+func TestTrapRegistration(t *testing.T) {
+	f := newFakeOSSignal(t)
+	h := Trap(func(os.Signal) {}, syscall.SIGHUP, syscall.SIGTERM)
+	first := h.notified
+	if got := f.notified[first]; !slices.Equal(got, []os.Signal{syscall.SIGHUP, syscall.SIGTERM}) {
+		t.Fatalf("registered %v, want [SIGHUP SIGTERM]", got)
+	}
+
+	h.Remove(syscall.SIGHUP)
+	if got := f.notified[h.notified]; !slices.Equal(got, []os.Signal{syscall.SIGTERM}) {
+		t.Errorf("registered %v after Remove, want [SIGTERM]", got)
+	}
+	// SIGTERM must stay trapped: the new registration comes before the old
+	// one is stopped.
+	if !slices.Equal(f.calls, []string{"notify", "notify", "stop"}) || f.stopped[0] != first {
+		t.Errorf("calls %q, want the kept signals registered before the first channel is stopped", f.calls)
+	}
+
+	h.Stop()
+	if len(f.notified) != 0 || h.notified != nil {
+		t.Errorf("%d channels still registered after Stop", len(f.notified))
+	}
+}
+
+func TestTrapEveryRegistration(t *testing.T) {
+	f := newFakeOSSignal(t)
+	h := Trap(func(os.Signal) {})
+	if got, ok := f.notified[h.notified]; !ok || len(got) != 0 {
+		t.Errorf("registered %v, want Notify without signals", got)
+	}
+	h.Stop()
+	if len(f.notified) != 0 {
+		t.Error("channel still registered after Stop")
+	}
+}
+
+func TestSnapshotRegistration(t *testing.T) {
+	f := newFakeOSSignal(t)
+	kept := Trap(func(os.Signal) {}, syscall.SIGHUP)
+	defer kept.Stop()
+	changed := Trap(func(os.Signal) {}, syscall.SIGHUP, syscall.SIGTERM)
+	defer changed.Stop()
+	restore := Snapshot()
+
+	changed.Remove(syscall.SIGHUP)
+	Trap(func(os.Signal) {}, syscall.SIGINT)
+	keptCh := kept.notified
+	f.calls = nil
+	restore()
+
+	if kept.notified != keptCh {
+		t.Error("unchanged TrapHandle registered again")
+	}
+	if got := f.notified[changed.notified]; !slices.Equal(got, []os.Signal{syscall.SIGHUP, syscall.SIGTERM}) {
+		t.Errorf("restored %v, want [SIGHUP SIGTERM]", got)
+	}
+	// One Stop for the added TrapHandle, then the changed one is registered
+	// again before its previous registration is stopped.
+	if !slices.Equal(f.calls, []string{"stop", "notify", "stop"}) {
+		t.Errorf("calls %q, want [stop notify stop]", f.calls)
+	}
+	if len(f.notified) != 2 {
+		t.Errorf("%d channels registered, want 2", len(f.notified))
+	}
+}
+
+func TestNotifyContextRegistration(t *testing.T) {
+	f := newFakeOSSignal(t)
+	_, stop := notifyContext(context.Background(), syscall.SIGTERM)
+	if len(f.notified) != 1 {
+		t.Fatalf("%d channels registered, want 1", len(f.notified))
+	}
+	stop()
+	stop()
+	if len(f.notified) != 0 || len(f.stopped) != 1 {
+		t.Errorf("after stop: %d channels registered and %d stopped, want 0 and 1", len(f.notified), len(f.stopped))
+	}
+}
@@ -0,0 +1,64 @@
+package grip
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// A Semaphore limits how much work runs at once. *semaphore.Weighted from
+// golang.org/x/sync satisfies it, as does ChanSemaphore.
+type Semaphore interface {
+	Acquire(ctx context.Context, n int64) error
+	Release(n int64)
+}
+
+// Quiesce creates an ExitHandler that acquires all permits of sem, waiting for
+// work in progress to release them and preventing new work from starting. The
+// permits are kept, so no new work can start for the rest of the shutdown.
+//
+//	sem := semaphore.NewWeighted(64) // also gates incoming requests
+//	grip.Exit(ch, os.Stderr, grip.Quiesce(sem, 64, 30*time.Second), closeDB)
+//
+// The ExitHandler fails with an error wrapping ErrTimeout if the permits are
+// not all available within timeout, in which case none are kept.
+func Quiesce(sem Semaphore, permits int64, timeout time.Duration) ExitHandler {
+	return func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		if err := sem.Acquire(ctx, permits); err != nil {
+			if errors.Is(err, context.DeadlineExceeded) {
+				return fmt.Errorf("work still running after %s: %w", timeout, ErrTimeout)
+			}
+			return err
+		}
+		return nil
+	}
+}
+
+// A ChanSemaphore is a Semaphore backed by a buffered channel whose capacity
+// is the number of permits: sending acquires a permit and receiving releases
+// one.
+type ChanSemaphore chan struct{}
+
+// Acquire acquires n permits, blocking until they are available or ctx is
+// done. On failure no permits are kept.
+func (s ChanSemaphore) Acquire(ctx context.Context, n int64) error {
+	for i := int64(0); i < n; i++ {
+		select {
+		case s <- struct{}{}:
+		case <-ctx.Done():
+			s.Release(i)
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// Release releases n permits.
+func (s ChanSemaphore) Release(n int64) {
+	for i := int64(0); i < n; i++ {
+		<-s
+	}
+}
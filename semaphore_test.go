@@ -0,0 +1,40 @@
+package grip_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/codycraven/grip"
+)
+
+func TestQuiesce(t *testing.T) {
+	sem := make(grip.ChanSemaphore, 2)
+	sem <- struct{}{} // work in progress
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		sem.Release(1)
+	}()
+	start := time.Now()
+	if err := grip.Quiesce(sem, 2, time.Second)(); err != nil {
+		t.Fatalf("Quiesce returned %v", err)
+	}
+	if time.Since(start) < 10*time.Millisecond {
+		t.Error("Quiesce returned before the work released its permit")
+	}
+	if len(sem) != 2 {
+		t.Errorf("%d permits held, want all 2 kept", len(sem))
+	}
+}
+
+func TestQuiesceTimeout(t *testing.T) {
+	sem := make(grip.ChanSemaphore, 2)
+	sem <- struct{}{}
+	err := grip.Quiesce(sem, 2, 10*time.Millisecond)()
+	if !errors.Is(err, grip.ErrTimeout) {
+		t.Errorf("Quiesce returned %v, want ErrTimeout", err)
+	}
+	if len(sem) != 1 {
+		t.Errorf("%d permits held, want only the one in use", len(sem))
+	}
+}
@@ -0,0 +1,184 @@
+package grip
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrShutdownInProgress is returned when a shutdown is requested while one is
+// already running.
+var ErrShutdownInProgress = errors.New("shutdown already in progress")
+
+// A Shutdowner runs ExitHandlers ordered by the dependencies declared between
+// them, so that each ExitHandler runs before the ExitHandlers of everything it
+// depends on:
+//
+//	sd := grip.NewShutdowner()
+//	sd.Register("db", closeDB)
+//	sd.Register("cache", closeCache)
+//	sd.Register("http", stopHTTP, grip.DependsOn("db", "cache"))
+//
+//	ch := make(chan int)
+//	grip.Trap(func(_ os.Signal) {
+//		code, err := sd.Run(os.Stderr)
+//		if err != nil {
+//			fmt.Fprintln(os.Stderr, err)
+//			code = 255
+//		}
+//		ch <- code
+//	}, syscall.SIGINT, syscall.SIGTERM)
+//
+// Here http is stopped first, followed by db and cache.
+//
+// A Shutdowner is safe for concurrent use.
+type Shutdowner struct {
+	mu      sync.Mutex
+	nodes   []*shutdownNode
+	running atomic.Bool
+}
+
+type shutdownNode struct {
+	name      string
+	fn        ExitHandler
+	dependsOn []string
+}
+
+// A RegisterOption configures an ExitHandler registered with a Shutdowner.
+type RegisterOption func(*shutdownNode)
+
+// DependsOn declares that a registered ExitHandler depends on the ExitHandlers
+// registered under names, so it runs before all of them.
+func DependsOn(names ...string) RegisterOption {
+	return func(n *shutdownNode) {
+		n.dependsOn = append(n.dependsOn, names...)
+	}
+}
+
+// NewShutdowner creates an empty Shutdowner.
+func NewShutdowner() *Shutdowner {
+	return &Shutdowner{}
+}
+
+// Register adds an ExitHandler under name. Dependencies may refer to names
+// that are registered later. Each name may be registered only once; Order and
+// Run report a name registered again as an error.
+func (sd *Shutdowner) Register(name string, fn ExitHandler, opts ...RegisterOption) {
+	n := &shutdownNode{name: name, fn: fn}
+	for _, opt := range opts {
+		opt(n)
+	}
+	sd.mu.Lock()
+	sd.nodes = append(sd.nodes, n)
+	sd.mu.Unlock()
+}
+
+// Order returns the registered names in the order Run calls them. ExitHandlers
+// that are not ordered by a dependency keep their registration order.
+//
+// Order returns an error if a name was registered more than once, if a
+// dependency refers to a name that was never registered or if the
+// dependencies form a cycle; calling it at startup catches these mistakes
+// before a shutdown relies on them.
+func (sd *Shutdowner) Order() ([]string, error) {
+	nodes, order, err := sd.order()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(order))
+	for i, idx := range order {
+		names[i] = nodes[idx].name
+	}
+	return names, nil
+}
+
+// Run calls the registered ExitHandlers in dependency order and returns the
+// bitmask exit code described by Exit.
+//
+// Bits are assigned by registration order rather than run order, so the first
+// registered ExitHandler adds 1 when it fails no matter where the dependencies
+// place it. Failures are written to errWriter prefixed with their name.
+//
+// Run returns an error without calling any ExitHandler if the dependencies
+// cannot be ordered, see Order, or ErrShutdownInProgress if another call to
+// Run has not finished yet.
+func (sd *Shutdowner) Run(errWriter io.Writer) (int, error) {
+	if !sd.running.CompareAndSwap(false, true) {
+		return 0, ErrShutdownInProgress
+	}
+	defer sd.running.Store(false)
+	nodes, order, err := sd.order()
+	if err != nil {
+		return 0, err
+	}
+	shutting.Store(true)
+	report := bitReporter(errWriter)
+	results := make([]HandlerResult, 0, len(order))
+	for _, idx := range order {
+		n := nodes[idx]
+		r := newResult(idx, n.fn())
+		if r.Failed() {
+			r.Err = fmt.Errorf("%s: %w", n.name, r.Err)
+			report(r)
+		}
+		results = append(results, r)
+	}
+	return Bitmask(results), nil
+}
+
+// order topologically sorts the registered nodes, dependents first. It returns
+// a snapshot of the nodes and their indexes in run order.
+func (sd *Shutdowner) order() ([]*shutdownNode, []int, error) {
+	sd.mu.Lock()
+	nodes := sd.nodes[:len(sd.nodes):len(sd.nodes)]
+	sd.mu.Unlock()
+
+	index := make(map[string]int, len(nodes))
+	for i, n := range nodes {
+		if _, ok := index[n.name]; ok {
+			return nil, nil, fmt.Errorf("%s registered more than once", n.name)
+		}
+		index[n.name] = i
+	}
+	// dependents counts, for each node, the nodes that must run before it.
+	dependents := make([]int, len(nodes))
+	for _, n := range nodes {
+		for _, dep := range n.dependsOn {
+			i, ok := index[dep]
+			if !ok {
+				return nil, nil, fmt.Errorf("%s depends on unregistered %s", n.name, dep)
+			}
+			dependents[i]++
+		}
+	}
+
+	order := make([]int, 0, len(nodes))
+	done := make([]bool, len(nodes))
+	for len(order) < len(nodes) {
+		next := -1
+		for i := range nodes {
+			if !done[i] && dependents[i] == 0 {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			var cycle []string
+			for i, n := range nodes {
+				if !done[i] {
+					cycle = append(cycle, n.name)
+				}
+			}
+			return nil, nil, fmt.Errorf("dependency cycle among %s", strings.Join(cycle, ", "))
+		}
+		done[next] = true
+		order = append(order, next)
+		for _, dep := range nodes[next].dependsOn {
+			dependents[index[dep]]--
+		}
+	}
+	return nodes, order, nil
+}
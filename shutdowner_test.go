@@ -0,0 +1,93 @@
+package grip_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/codycraven/grip"
+)
+
+func TestShutdownerOrder(t *testing.T) {
+	var ran []string
+	step := func(name string, err error) grip.ExitHandler {
+		return func() error {
+			ran = append(ran, name)
+			return err
+		}
+	}
+	sd := grip.NewShutdowner()
+	sd.Register("db", step("db", errors.New("db")))
+	sd.Register("cache", step("cache", nil))
+	sd.Register("http", step("http", nil), grip.DependsOn("db", "cache"))
+	sd.Register("metrics", step("metrics", nil), grip.DependsOn("http"))
+
+	order, err := sd.Order()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "metrics http db cache"; strings.Join(order, " ") != want {
+		t.Errorf("Order() = %v, want %s", order, want)
+	}
+
+	var buf strings.Builder
+	code, err := sd.Run(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(ran, " ") != strings.Join(order, " ") {
+		t.Errorf("ran %v, want %v", ran, order)
+	}
+	// Bits follow registration order: db was registered first.
+	if code != 1 {
+		t.Errorf("exit code %d, want 1", code)
+	}
+	if !strings.Contains(buf.String(), "db: db") {
+		t.Errorf("output %q does not name the failed ExitHandler", buf.String())
+	}
+}
+
+func TestShutdownerCycle(t *testing.T) {
+	called := false
+	fn := func() error {
+		called = true
+		return nil
+	}
+	sd := grip.NewShutdowner()
+	sd.Register("a", fn, grip.DependsOn("b"))
+	sd.Register("b", fn, grip.DependsOn("c"))
+	sd.Register("c", fn, grip.DependsOn("a"))
+	if _, err := sd.Order(); err == nil {
+		t.Error("Order() returned no error for a cycle")
+	}
+	if _, err := sd.Run(nil); err == nil {
+		t.Error("Run returned no error for a cycle")
+	}
+	if called {
+		t.Error("Run called an ExitHandler despite the cycle")
+	}
+}
+
+func TestShutdownerUnknownDependency(t *testing.T) {
+	sd := grip.NewShutdowner()
+	sd.Register("http", pass, grip.DependsOn("db"))
+	if _, err := sd.Order(); err == nil {
+		t.Error("Order() returned no error for an unregistered dependency")
+	}
+}
+
+func TestShutdownerDuplicateName(t *testing.T) {
+	called := false
+	sd := grip.NewShutdowner()
+	sd.Register("db", pass)
+	sd.Register("db", func() error {
+		called = true
+		return nil
+	})
+	if _, err := sd.Order(); err == nil {
+		t.Error("Order() returned no error for a name registered twice")
+	}
+	if _, err := sd.Run(nil); err == nil || called {
+		t.Errorf("Run = %v, called %t, want an error without calling anything", err, called)
+	}
+}
@@ -0,0 +1,425 @@
+package grip
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Timed creates a SignalHandler that measures how long another SignalHandler
+// takes and writes "<label> took <duration>" to an io.Writer once it returns.
+//
+// Timed can be nested to measure individual segments of a chain:
+//
+//	grip.Trap(
+//		grip.Timed("shutdown", os.Stderr, grip.Message("received shutdown request", os.Stdout,
+//			grip.Timed("exit handlers", os.Stderr, grip.Exit(ch, os.Stderr, closeDB)),
+//		)),
+//		syscall.SIGINT, syscall.SIGTERM,
+//	)
+func Timed(label string, w io.Writer, fn SignalHandler) SignalHandler {
+	w = orDiscard(w)
+	return func(s os.Signal) {
+		start := time.Now()
+		fn(s)
+		fmt.Fprintf(w, "%s took %s\n", label, time.Since(start))
+	}
+}
+
+// MessageSync is like Message but flushes the io.Writer after writing, so the
+// message is not lost if a later SignalHandler exits the process.
+//
+// A writer with a Flush() error method, such as a *bufio.Writer, is flushed and
+// a writer with a Sync() error method, such as an *os.File, is synced. Errors
+// from either are ignored since there is nowhere left to report them.
+func MessageSync(m string, w io.Writer, fn SignalHandler) SignalHandler {
+	return Message(m, w, func(s os.Signal) {
+		syncWriter(w)
+		fn(s)
+	})
+}
+
+// syncWriter flushes and syncs w if it supports either.
+func syncWriter(w io.Writer) {
+	if f, ok := w.(interface{ Flush() error }); ok {
+		f.Flush()
+	}
+	if f, ok := w.(interface{ Sync() error }); ok {
+		f.Sync()
+	}
+}
+
+// JSONMessage is like Message but writes a single JSON object per signal,
+// suitable for structured log collectors:
+//
+//	{"msg":"signal received","signal":"terminated","time":"2006-01-02T15:04:05Z","service":"api"}
+//
+// The object contains msg, signal and time (RFC 3339) followed by fields,
+// which may override any of them.
+//
+//	grip.Trap(
+//		grip.JSONMessage(os.Stdout, map[string]any{"service": "api"}, grip.Exit(ch, os.Stderr, closeDB)),
+//		syscall.SIGINT, syscall.SIGTERM,
+//	)
+func JSONMessage(w io.Writer, fields map[string]any, fn SignalHandler) SignalHandler {
+	w = orDiscard(w)
+	return func(s os.Signal) {
+		entry := map[string]any{
+			"msg":    "signal received",
+			"signal": s.String(),
+			"time":   time.Now().Format(time.RFC3339Nano),
+		}
+		for k, v := range fields {
+			entry[k] = v
+		}
+		if b, err := json.Marshal(entry); err == nil {
+			fmt.Fprintf(w, "%s\n", b)
+		}
+		fn(s)
+	}
+}
+
+// ExitZero is a SignalHandler that calls ExitFunc with exit code 0. It is the
+// simplest way for a CLI to quit cleanly on Ctrl-C:
+//
+//	grip.Trap(grip.ExitZero, grip.InterruptSignals()...)
+func ExitZero(_ os.Signal) {
+	ExitFunc(0)
+}
+
+// Stats creates a SignalHandler that writes runtime diagnostics to an
+// io.Writer: time since start, the number of goroutines and memory statistics.
+// It does not exit, so it pairs with TrapRepeat to print fresh statistics on
+// every signal:
+//
+//	grip.TrapRepeat(grip.Stats(os.Stderr, time.Now()), syscall.SIGUSR1)
+//
+// TrapStats does this on platforms with SIGUSR1.
+func Stats(w io.Writer, start time.Time) SignalHandler {
+	w = orDiscard(w)
+	return func(s os.Signal) {
+		var m runtime.MemStats
+		runtime.ReadMemStats(&m)
+		fmt.Fprintf(w, "%s: uptime=%s goroutines=%d heap_alloc=%d heap_sys=%d sys=%d num_gc=%d\n",
+			s, time.Since(start).Round(time.Millisecond), runtime.NumGoroutine(),
+			m.HeapAlloc, m.HeapSys, m.Sys, m.NumGC)
+	}
+}
+
+// Escalate creates a SignalHandler that calls steps[n-1] for the nth signal it
+// receives, repeating the last step for any further signals. It encodes
+// progressively more forceful shutdowns with TrapRepeat:
+//
+//	grip.TrapRepeat(grip.Escalate(
+//		grip.Exit(ch, os.Stderr, stopHTTP, closeDB), // graceful
+//		func(_ os.Signal) { forceClose() },          // forceful
+//		func(_ os.Signal) { grip.ExitFunc(130) },    // immediate
+//	), syscall.SIGINT)
+//
+// Each step runs in its own goroutine so that a later signal can escalate
+// while an earlier step is still running.
+func Escalate(steps ...SignalHandler) SignalHandler {
+	var n atomic.Int64
+	return func(s os.Signal) {
+		if len(steps) == 0 {
+			return
+		}
+		i := min(int(n.Add(1))-1, len(steps)-1)
+		go steps[i](s)
+	}
+}
+
+// Chain creates a SignalHandler that calls each SignalHandler in order.
+func Chain(fn ...SignalHandler) SignalHandler {
+	return func(s os.Signal) {
+		for _, f := range fn {
+			f(s)
+		}
+	}
+}
+
+// Then creates a SignalHandler that calls fn and then runs the exit sequence
+// of Exit, bridging a SignalHandler such as one that logs or relays the signal
+// with the ExitHandlers. The exit code is sent to the returned channel:
+//
+//	fn, codes := grip.Then(grip.Relay(cmd.Process), stopHTTP, closeDB)
+//	grip.Trap(fn, syscall.SIGINT, syscall.SIGTERM)
+//	os.Exit(<-codes)
+//
+// Failed ExitHandlers are written to os.Stderr. The channel holds one exit
+// code, so the SignalHandler does not block on it; like the Exit family, a
+// call made while the sequence is still running is dropped.
+func Then(fn SignalHandler, exit ...ExitHandler) (SignalHandler, <-chan int) {
+	ch := make(chan int, 1)
+	return exclusive(WriterSink(os.Stderr), func(s os.Signal) {
+		fn(s)
+		ch <- exitCode(os.Stderr, exit)
+	}), ch
+}
+
+// GateUntil creates a SignalHandler that only calls fn once ready has been
+// closed, so shutdown never runs against half-initialized resources such as a
+// nil database or server:
+//
+//	ready := make(chan struct{})
+//	grip.Trap(grip.GateUntil(ready, grip.Exit(ch, os.Stderr, stopHTTP, closeDB)), syscall.SIGINT, syscall.SIGTERM)
+//	db = openDB()
+//	server = startHTTP()
+//	close(ready)
+//
+// A signal arriving before ready is closed does not wait for startup, which
+// may itself be what is stuck. Instead it is a safe minimal exit: fn is not
+// called and ExitFunc is called straight away with 128 plus the signal number,
+// the code a shell reports for a process killed by the signal, or 1 for
+// signals without a number. The process exiting releases whatever startup had
+// acquired so far.
+func GateUntil(ready <-chan struct{}, fn SignalHandler) SignalHandler {
+	return func(s os.Signal) {
+		select {
+		case <-ready:
+			fn(s)
+			return
+		default:
+		}
+		code := 1
+		if n, ok := signum(s); ok {
+			code = 128 + n
+		}
+		ExitFunc(code)
+	}
+}
+
+// Recorder returns a SignalHandler that records the signal it receives and a
+// function returning the most recently recorded signal, or nil before the
+// first one. Chained ahead of the real SignalHandler it lets tests, or a debug
+// endpoint, see which signal arrived last:
+//
+//	record, last := grip.Recorder()
+//	grip.Trap(grip.Chain(record, grip.Exit(ch, os.Stderr, closeDB)), syscall.SIGINT, syscall.SIGTERM)
+//
+// Both functions are safe for concurrent use.
+func Recorder() (SignalHandler, func() os.Signal) {
+	var (
+		mu   sync.Mutex
+		last os.Signal
+	)
+	record := func(s os.Signal) {
+		mu.Lock()
+		last = s
+		mu.Unlock()
+	}
+	get := func() os.Signal {
+		mu.Lock()
+		defer mu.Unlock()
+		return last
+	}
+	return record, get
+}
+
+// OnSignal creates a SignalHandler that passes each signal to inc, typically
+// the increment of a metric counting received signals by type, and then
+// chains to another SignalHandler. It wires grip to any metrics library:
+//
+//	received := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "signals_received_total"}, []string{"signal"})
+//	grip.Trap(grip.OnSignal(func(s os.Signal) {
+//		received.WithLabelValues(s.String()).Inc()
+//	}, grip.Exit(ch, os.Stderr, closeDB)), syscall.SIGINT, syscall.SIGTERM)
+//
+// inc is called before fn, so the signal is counted even if fn never returns.
+func OnSignal(inc func(os.Signal), fn SignalHandler) SignalHandler {
+	return func(s os.Signal) {
+		inc(s)
+		fn(s)
+	}
+}
+
+// Tee creates a SignalHandler that forwards each signal to userCh and then
+// chains to another SignalHandler, giving custom code an observable stream of
+// the signals grip delivers.
+//
+//	seen := make(chan os.Signal, 8)
+//	grip.TrapRepeat(grip.Tee(seen, reload), syscall.SIGHUP)
+//	go audit(seen)
+//
+// The forward never blocks: like os/signal, a signal is dropped for userCh if
+// it is full, so give it enough buffer for the expected rate of signals.
+func Tee(userCh chan<- os.Signal, fn SignalHandler) SignalHandler {
+	return func(s os.Signal) {
+		select {
+		case userCh <- s:
+		default:
+		}
+		fn(s)
+	}
+}
+
+// Echo creates a SignalHandler that echoes the signal to an io.Writer the way
+// a terminal would, then chains to another SignalHandler. SIGINT is written as
+// ^C and SIGQUIT as ^\, while other signals are written by name.
+//
+//	grip.Trap(grip.Echo(os.Stderr, grip.Exit(ch, os.Stderr, cleanup)), syscall.SIGINT)
+func Echo(w io.Writer, fn SignalHandler) SignalHandler {
+	w = orDiscard(w)
+	return func(s os.Signal) {
+		fmt.Fprintln(w, caret(s))
+		fn(s)
+	}
+}
+
+// caret returns the caret notation of the key that sends s, or its name.
+func caret(s os.Signal) string {
+	switch {
+	case s == SIGINT:
+		return "^C"
+	case SIGQUIT != nil && s == SIGQUIT:
+		return `^\`
+	}
+	return s.String()
+}
+
+// ChainCtx creates a SignalHandler that calls each handler in order, passing
+// each one the context returned by the previous one so data can flow through
+// a shutdown pipeline without globals:
+//
+//	type startKey struct{}
+//	grip.Trap(grip.ChainCtx(
+//		func(ctx context.Context, _ os.Signal) context.Context {
+//			return context.WithValue(ctx, startKey{}, time.Now())
+//		},
+//		func(ctx context.Context, s os.Signal) context.Context {
+//			log.Printf("%s handled in %s", s, time.Since(ctx.Value(startKey{}).(time.Time)))
+//			return ctx
+//		},
+//	), syscall.SIGTERM)
+//
+// The first handler receives context.Background(); use ChainCtxFrom to start
+// from another context. A handler returning nil passes its own context on
+// unchanged.
+func ChainCtx(handlers ...func(context.Context, os.Signal) context.Context) SignalHandler {
+	return ChainCtxFrom(context.Background(), handlers...)
+}
+
+// ChainCtxFrom is like ChainCtx but the first handler receives base.
+func ChainCtxFrom(base context.Context, handlers ...func(context.Context, os.Signal) context.Context) SignalHandler {
+	return func(s os.Signal) {
+		ctx := base
+		for _, h := range handlers {
+			if next := h(ctx, s); next != nil {
+				ctx = next
+			}
+		}
+	}
+}
+
+// Once creates a SignalHandler that calls fn for the first signal only and
+// drops every later one, even once fn has returned.
+func Once(fn SignalHandler) SignalHandler {
+	var once sync.Once
+	return func(s os.Signal) {
+		once.Do(func() {
+			fn(s)
+		})
+	}
+}
+
+// SingleFlight creates a SignalHandler that coalesces overlapping calls: a
+// call made while fn is already running for an earlier signal does not call
+// fn again but waits for the running call to finish, so every caller returns
+// only once the shared execution has completed. A call made after fn has
+// returned starts a new execution.
+//
+// Unlike Once, later signals are not dropped, and unlike the Exit family's
+// protection against overlapping shutdowns, the second caller blocks until the
+// first one is done:
+//
+//	shutdown := grip.SingleFlight(grip.Exit(ch, os.Stderr, closeDB))
+//	grip.Trap(shutdown, syscall.SIGINT)
+//	grip.Trap(shutdown, syscall.SIGTERM)
+func SingleFlight(fn SignalHandler) SignalHandler {
+	var (
+		mu     sync.Mutex
+		flight chan struct{}
+	)
+	return func(s os.Signal) {
+		mu.Lock()
+		if flight != nil {
+			wait := flight
+			mu.Unlock()
+			<-wait
+			return
+		}
+		flight = make(chan struct{})
+		mu.Unlock()
+
+		defer func() {
+			mu.Lock()
+			close(flight)
+			flight = nil
+			mu.Unlock()
+		}()
+		fn(s)
+	}
+}
+
+// TwoPhase creates a SignalHandler for the common interactive shutdown: the
+// first signal runs softFn, a graceful shutdown, and a second signal of the
+// same type calls ExitFunc with hardCode without waiting for it to finish:
+//
+//	grip.TrapRepeat(grip.TwoPhase(grip.Exit(ch, os.Stderr, stopHTTP, closeDB), 130), syscall.SIGINT)
+//
+// softFn runs in its own goroutine so the second signal can be handled while
+// it is still running. Other signals after the first are ignored.
+func TwoPhase(softFn SignalHandler, hardCode int) SignalHandler {
+	var (
+		mu    sync.Mutex
+		first os.Signal
+	)
+	return func(s os.Signal) {
+		mu.Lock()
+		if first == nil {
+			first = s
+			mu.Unlock()
+			go softFn(s)
+			return
+		}
+		hard := s == first
+		mu.Unlock()
+		if hard {
+			ExitFunc(hardCode)
+		}
+	}
+}
+
+// Confirm creates a SignalHandler that asks for confirmation before chaining
+// to fn, for developer-facing command line tools where Ctrl-C should not
+// discard work by accident:
+//
+//	grip.TrapRepeat(grip.Confirm(os.Stdin, os.Stderr, grip.Exit(ch, os.Stderr, save)), syscall.SIGINT)
+//
+// It writes "Really quit? [y/N] " to out and reads a line from in. Only "y" or
+// "yes", in any case, calls fn; any other answer returns and the program keeps
+// running. Reaching the end of in, as with input that is not a terminal,
+// counts as no.
+func Confirm(in io.Reader, out io.Writer, fn SignalHandler) SignalHandler {
+	out = orDiscard(out)
+	r := bufio.NewReader(in)
+	var mu sync.Mutex
+	return func(s os.Signal) {
+		mu.Lock()
+		fmt.Fprint(out, "Really quit? [y/N] ")
+		line, _ := r.ReadString('\n')
+		mu.Unlock()
+		switch strings.ToLower(strings.TrimSpace(line)) {
+		case "y", "yes":
+			fn(s)
+		}
+	}
+}
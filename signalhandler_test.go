@@ -0,0 +1,356 @@
+//go:build !js
+
+package grip_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/codycraven/grip"
+	"github.com/codycraven/grip/griptest"
+)
+
+func TestTimed(t *testing.T) {
+	var buf bytes.Buffer
+	const sleep = 20 * time.Millisecond
+	grip.Timed("shutdown", &buf, func(os.Signal) {
+		time.Sleep(sleep)
+	})(syscall.SIGTERM)
+
+	out := strings.TrimSpace(buf.String())
+	took, ok := strings.CutPrefix(out, "shutdown took ")
+	if !ok {
+		t.Fatalf("output %q does not start with the label", out)
+	}
+	d, err := time.ParseDuration(took)
+	if err != nil {
+		t.Fatalf("output %q: %v", out, err)
+	}
+	if d < sleep {
+		t.Errorf("reported %s, want at least %s", d, sleep)
+	}
+}
+
+func TestExitZero(t *testing.T) {
+	exits := griptest.CaptureExit(t)
+	grip.ExitZero(syscall.SIGINT)
+	if code := <-exits; code != 0 {
+		t.Errorf("exit code %d, want 0", code)
+	}
+}
+
+func TestMessageSyncFile(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	grip.MessageSync("received shutdown request", f, func(os.Signal) {
+		b, err := os.ReadFile(f.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := string(b), "received shutdown request: terminated\n"; got != want {
+			t.Errorf("file holds %q before the next SignalHandler, want %q", got, want)
+		}
+	})(syscall.SIGTERM)
+}
+
+func TestMessageSyncBuffered(t *testing.T) {
+	var buf bytes.Buffer
+	w := bufio.NewWriter(&buf)
+	grip.MessageSync("received shutdown request", w, func(os.Signal) {
+		if got, want := buf.String(), "received shutdown request: terminated\n"; got != want {
+			t.Errorf("writer holds %q before the next SignalHandler, want %q", got, want)
+		}
+	})(syscall.SIGTERM)
+}
+
+func TestJSONMessage(t *testing.T) {
+	var buf bytes.Buffer
+	called := false
+	grip.JSONMessage(&buf, map[string]any{"service": "api"}, func(os.Signal) {
+		called = true
+	})(syscall.SIGTERM)
+	if !called {
+		t.Error("next SignalHandler was not called")
+	}
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("output %q is not valid JSON: %v", buf.String(), err)
+	}
+	for k, want := range map[string]any{"msg": "signal received", "signal": syscall.SIGTERM.String(), "service": "api"} {
+		if entry[k] != want {
+			t.Errorf("%s = %v, want %v", k, entry[k], want)
+		}
+	}
+	if _, err := time.Parse(time.RFC3339Nano, entry["time"].(string)); err != nil {
+		t.Errorf("time: %v", err)
+	}
+}
+
+func TestStats(t *testing.T) {
+	var buf bytes.Buffer
+	grip.Stats(&buf, time.Now().Add(-time.Minute))(syscall.SIGTERM)
+	out := buf.String()
+	if !strings.HasPrefix(out, syscall.SIGTERM.String()+": uptime=1m0") {
+		t.Errorf("output %q does not start with the signal and uptime", out)
+	}
+	for _, field := range []string{"goroutines=", "heap_alloc=", "num_gc="} {
+		if !strings.Contains(out, field) {
+			t.Errorf("output %q does not contain %s", out, field)
+		}
+	}
+}
+
+func TestEscalate(t *testing.T) {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	counts := make([]int, 3)
+	steps := make([]grip.SignalHandler, len(counts))
+	for i := range steps {
+		i := i
+		steps[i] = func(os.Signal) {
+			defer wg.Done()
+			mu.Lock()
+			counts[i]++
+			mu.Unlock()
+		}
+	}
+	h := grip.Escalate(steps...)
+	wg.Add(4)
+	for i := 0; i < 4; i++ {
+		h(syscall.SIGINT)
+	}
+	wg.Wait()
+	if counts[0] != 1 || counts[1] != 1 || counts[2] != 2 {
+		t.Errorf("steps ran %v times, want [1 1 2]", counts)
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	record, last := grip.Recorder()
+	if s := last(); s != nil {
+		t.Fatalf("last() = %v before any signal, want nil", s)
+	}
+	var wg sync.WaitGroup
+	for _, s := range []os.Signal{syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP} {
+		wg.Add(1)
+		go func(s os.Signal) {
+			defer wg.Done()
+			record(s)
+			_ = last()
+		}(s)
+	}
+	wg.Wait()
+	if last() == nil {
+		t.Error("last() = nil after concurrent signals")
+	}
+	grip.Chain(record, func(os.Signal) {})(syscall.SIGTERM)
+	if s := last(); s != syscall.SIGTERM {
+		t.Errorf("last() = %v, want SIGTERM", s)
+	}
+}
+
+func TestTee(t *testing.T) {
+	seen := make(chan os.Signal, 1)
+	calls := 0
+	h := grip.Tee(seen, func(os.Signal) { calls++ })
+	h(syscall.SIGHUP)
+	h(syscall.SIGTERM) // seen is full, so only fn gets it
+	if calls != 2 {
+		t.Errorf("fn called %d times, want 2", calls)
+	}
+	if s := <-seen; s != syscall.SIGHUP {
+		t.Errorf("forwarded %v, want SIGHUP", s)
+	}
+	if len(seen) != 0 {
+		t.Error("signal forwarded to a full channel")
+	}
+}
+
+func TestChainCtx(t *testing.T) {
+	type key struct{}
+	var got any
+	grip.ChainCtx(
+		func(ctx context.Context, s os.Signal) context.Context {
+			return context.WithValue(ctx, key{}, s.String())
+		},
+		func(ctx context.Context, _ os.Signal) context.Context {
+			return nil // keeps the context as it is
+		},
+		func(ctx context.Context, _ os.Signal) context.Context {
+			got = ctx.Value(key{})
+			return ctx
+		},
+	)(syscall.SIGTERM)
+	if got != syscall.SIGTERM.String() {
+		t.Errorf("value %v did not propagate to the last handler", got)
+	}
+}
+
+func TestSingleFlight(t *testing.T) {
+	var calls atomic.Int32
+	started, release := make(chan struct{}), make(chan struct{})
+	h := grip.SingleFlight(func(os.Signal) {
+		if calls.Add(1) == 1 {
+			close(started)
+		}
+		<-release
+	})
+
+	var returned atomic.Int32
+	var wg sync.WaitGroup
+	go func() {
+		h(syscall.SIGINT)
+		returned.Add(1)
+	}()
+	<-started
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h(syscall.SIGTERM)
+			returned.Add(1)
+		}()
+	}
+	// Give the overlapping callers time to reach the running execution.
+	time.Sleep(50 * time.Millisecond)
+	if n := returned.Load(); n != 0 {
+		t.Fatalf("%d callers returned before the shared execution finished", n)
+	}
+	close(release)
+	wg.Wait()
+	if n := calls.Load(); n != 1 {
+		t.Errorf("fn ran %d times for overlapping calls, want 1", n)
+	}
+
+	h(syscall.SIGTERM)
+	if n := calls.Load(); n != 2 {
+		t.Errorf("fn ran %d times after a later call, want 2", n)
+	}
+}
+
+func TestTwoPhase(t *testing.T) {
+	exits := griptest.CaptureExit(t)
+	release := make(chan struct{})
+	defer close(release)
+	soft := make(chan os.Signal, 1)
+	h := grip.TwoPhase(func(s os.Signal) {
+		soft <- s
+		<-release
+	}, 130)
+
+	h(syscall.SIGINT)
+	want(t, soft, syscall.SIGINT)
+	// A different signal is ignored while the graceful shutdown runs.
+	h(syscall.SIGTERM)
+	h(syscall.SIGINT)
+	select {
+	case code := <-exits:
+		if code != 130 {
+			t.Errorf("exit code %d, want 130", code)
+		}
+	default:
+		t.Fatal("second SIGINT did not force an exit")
+	}
+	select {
+	case code := <-exits:
+		t.Errorf("unexpected exit with %d", code)
+	case s := <-soft:
+		t.Errorf("graceful shutdown ran again for %s", s)
+	default:
+	}
+}
+
+func TestOnSignal(t *testing.T) {
+	var counts []os.Signal
+	h := grip.OnSignal(func(s os.Signal) { counts = append(counts, s) }, func(s os.Signal) {
+		if len(counts) == 0 || counts[len(counts)-1] != s {
+			t.Errorf("%s chained before the callback received it", s)
+		}
+	})
+	h(syscall.SIGHUP)
+	h(syscall.SIGTERM)
+	if len(counts) != 2 || counts[0] != syscall.SIGHUP || counts[1] != syscall.SIGTERM {
+		t.Errorf("callback received %v, want [SIGHUP SIGTERM]", counts)
+	}
+}
+
+func TestThen(t *testing.T) {
+	var order []string
+	fn, codes := grip.Then(func(s os.Signal) { order = append(order, "signal "+s.String()) },
+		func() error {
+			order = append(order, "exit handler")
+			return nil
+		},
+	)
+	fn(syscall.SIGTERM)
+	if code := <-codes; code != 0 {
+		t.Errorf("exit code %d, want 0", code)
+	}
+	want := []string{"signal terminated", "exit handler"}
+	if !slices.Equal(order, want) {
+		t.Errorf("order %q, want %q", order, want)
+	}
+}
+
+func TestGateUntil(t *testing.T) {
+	exits := griptest.CaptureExit(t)
+	ready := make(chan struct{})
+	fn, got := recv()
+	h := grip.GateUntil(ready, fn)
+
+	h(syscall.SIGTERM)
+	none(t, got)
+	select {
+	case code := <-exits:
+		if code != 143 {
+			t.Errorf("exit code %d before ready, want 143", code)
+		}
+	default:
+		t.Error("no safe exit for a signal before ready")
+	}
+
+	close(ready)
+	h(syscall.SIGINT)
+	want(t, got, syscall.SIGINT)
+	select {
+	case code := <-exits:
+		t.Errorf("exit with %d after ready", code)
+	default:
+	}
+}
+
+func TestConfirm(t *testing.T) {
+	var out bytes.Buffer
+	fn, got := recv()
+	h := grip.Confirm(strings.NewReader("n\n YES \nmaybe\ny\n"), &out, fn)
+
+	h(syscall.SIGINT)
+	none(t, got)
+	h(syscall.SIGINT)
+	want(t, got, syscall.SIGINT)
+	h(syscall.SIGINT)
+	none(t, got)
+	h(syscall.SIGTERM)
+	want(t, got, syscall.SIGTERM)
+	// The input is exhausted, which counts as no.
+	h(syscall.SIGINT)
+	none(t, got)
+	if n := strings.Count(out.String(), "Really quit? [y/N] "); n != 5 {
+		t.Errorf("prompted %d times, want 5", n)
+	}
+}
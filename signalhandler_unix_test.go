@@ -0,0 +1,33 @@
+//go:build unix
+
+package grip_test
+
+import (
+	"bytes"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/codycraven/grip"
+)
+
+func TestEcho(t *testing.T) {
+	for _, tt := range []struct {
+		sig  os.Signal
+		want string
+	}{
+		{syscall.SIGINT, "^C\n"},
+		{syscall.SIGQUIT, "^\\\n"},
+		{syscall.SIGTERM, syscall.SIGTERM.String() + "\n"},
+	} {
+		var buf bytes.Buffer
+		var got os.Signal
+		grip.Echo(&buf, func(s os.Signal) { got = s })(tt.sig)
+		if buf.String() != tt.want {
+			t.Errorf("%v echoed %q, want %q", tt.sig, buf.String(), tt.want)
+		}
+		if got != tt.sig {
+			t.Errorf("%v: next SignalHandler got %v", tt.sig, got)
+		}
+	}
+}
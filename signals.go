@@ -0,0 +1,59 @@
+package grip
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// InterruptSignals returns the signals conventionally used to ask a process to
+// shut down: SIGINT (os.Interrupt, Ctrl-C) and SIGTERM where available.
+func InterruptSignals() []os.Signal {
+	return present(SIGINT, SIGTERM)
+}
+
+// Catchable returns every signal variable defined by this package that is
+// available on the current platform and can be passed to Trap.
+//
+// On Unix this is SIGHUP, SIGINT, SIGQUIT, SIGTERM, SIGUSR1, SIGUSR2 and
+// SIGPIPE. On Windows only SIGINT (Ctrl-C and Ctrl-Break) and SIGTERM (console
+// close, logoff and shutdown) are delivered to a process.
+func Catchable() []os.Signal {
+	return present(SIGHUP, SIGINT, SIGQUIT, SIGTERM, SIGUSR1, SIGUSR2, SIGPIPE)
+}
+
+// present returns the signals that are not nil.
+func present(signals ...os.Signal) []os.Signal {
+	var out []os.Signal
+	for _, s := range signals {
+		if s != nil {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// ParseSignal returns the signal variable of this package named name, such as
+// "SIGTERM". The name is case-insensitive and the SIG prefix is optional, so
+// "term" works too. It returns an error for unknown names and for signals that
+// are unavailable on the current platform.
+func ParseSignal(name string) (os.Signal, error) {
+	names := map[string]os.Signal{
+		"HUP":  SIGHUP,
+		"INT":  SIGINT,
+		"QUIT": SIGQUIT,
+		"TERM": SIGTERM,
+		"USR1": SIGUSR1,
+		"USR2": SIGUSR2,
+		"PIPE": SIGPIPE,
+	}
+	key := strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(name)), "SIG")
+	s, ok := names[key]
+	if !ok {
+		return nil, fmt.Errorf("unknown signal %q", name)
+	}
+	if s == nil {
+		return nil, fmt.Errorf("signal %s is unavailable on this platform", name)
+	}
+	return s, nil
+}
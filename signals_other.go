@@ -0,0 +1,18 @@
+//go:build !unix && !windows
+
+package grip
+
+import "os"
+
+// Signals available on platforms other than Unix and Windows. Only SIGINT is
+// available; the others are nil so code using them still compiles. See
+// Catchable.
+var (
+	SIGHUP  os.Signal
+	SIGINT  os.Signal = os.Interrupt
+	SIGQUIT os.Signal
+	SIGTERM os.Signal
+	SIGUSR1 os.Signal
+	SIGUSR2 os.Signal
+	SIGPIPE os.Signal
+)
@@ -0,0 +1,21 @@
+//go:build unix
+
+package grip
+
+import (
+	"os"
+	"syscall"
+)
+
+// Signals available on Unix. They are defined on every platform so code using
+// them compiles everywhere, but are nil where the signal is unavailable; see
+// Catchable.
+var (
+	SIGHUP  os.Signal = syscall.SIGHUP
+	SIGINT  os.Signal = syscall.SIGINT
+	SIGQUIT os.Signal = syscall.SIGQUIT
+	SIGTERM os.Signal = syscall.SIGTERM
+	SIGUSR1 os.Signal = syscall.SIGUSR1
+	SIGUSR2 os.Signal = syscall.SIGUSR2
+	SIGPIPE os.Signal = syscall.SIGPIPE
+)
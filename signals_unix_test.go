@@ -0,0 +1,31 @@
+//go:build unix
+
+package grip_test
+
+import (
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/codycraven/grip"
+)
+
+func TestCatchable(t *testing.T) {
+	want := []os.Signal{syscall.SIGHUP, syscall.SIGINT, syscall.SIGQUIT, syscall.SIGTERM, syscall.SIGUSR1, syscall.SIGUSR2, syscall.SIGPIPE}
+	got := grip.Catchable()
+	if len(got) != len(want) {
+		t.Fatalf("Catchable() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Catchable()[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestInterruptSignals(t *testing.T) {
+	got := grip.InterruptSignals()
+	if len(got) != 2 || got[0] != syscall.SIGINT || got[1] != syscall.SIGTERM {
+		t.Errorf("InterruptSignals() = %v, want [SIGINT SIGTERM]", got)
+	}
+}
@@ -0,0 +1,21 @@
+//go:build windows
+
+package grip
+
+import (
+	"os"
+	"syscall"
+)
+
+// Signals available on Windows. Only SIGINT and SIGTERM are delivered to a
+// process; the others are nil so code using them still compiles. See
+// Catchable.
+var (
+	SIGHUP  os.Signal
+	SIGINT  os.Signal = os.Interrupt
+	SIGQUIT os.Signal
+	SIGTERM os.Signal = syscall.SIGTERM
+	SIGUSR1 os.Signal
+	SIGUSR2 os.Signal
+	SIGPIPE os.Signal
+)
@@ -0,0 +1,14 @@
+//go:build !plan9
+
+package grip
+
+import (
+	"os"
+	"syscall"
+)
+
+// signum returns the number of s, if it has one.
+func signum(s os.Signal) (int, bool) {
+	n, ok := s.(syscall.Signal)
+	return int(n), ok
+}
@@ -0,0 +1,9 @@
+package grip
+
+import "os"
+
+// signum returns the number of s, if it has one. Plan 9 notes are strings, so
+// signals never have a number.
+func signum(s os.Signal) (int, bool) {
+	return 0, false
+}
@@ -0,0 +1,24 @@
+//go:build unix
+
+package grip
+
+import "syscall"
+
+// IgnoreSIGPIPE stops SIGPIPE from terminating the process.
+//
+// By default a Go program is killed by SIGPIPE when it writes to a closed pipe on
+// standard output or standard error, which is what happens to a CLI tool piped
+// into head once head has read enough. Writes to other broken pipes or sockets
+// already just fail with EPIPE. After IgnoreSIGPIPE, writes to standard output
+// and standard error fail with EPIPE too, so check their errors and stop
+// quietly on syscall.EPIPE:
+//
+//	grip.IgnoreSIGPIPE()
+//	if _, err := fmt.Println(line); errors.Is(err, syscall.EPIPE) {
+//		os.Exit(0)
+//	}
+//
+// IgnoreSIGPIPE is only available on Unix, the only platform with SIGPIPE.
+func IgnoreSIGPIPE() {
+	osSignal.Ignore(syscall.SIGPIPE)
+}
@@ -0,0 +1,56 @@
+//go:build unix
+
+package grip_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/codycraven/grip"
+)
+
+func TestIgnoreSIGPIPE(t *testing.T) {
+	if mode := os.Getenv("GRIP_TEST_SIGPIPE"); mode != "" {
+		if mode == "ignore" {
+			grip.IgnoreSIGPIPE()
+		}
+		for {
+			if _, err := fmt.Println("line"); err != nil {
+				fmt.Fprintf(os.Stderr, "survived: %v\n", errors.Is(err, syscall.EPIPE))
+				os.Exit(0)
+			}
+		}
+	}
+
+	for _, tt := range []struct {
+		mode     string
+		survives bool
+	}{
+		{"ignore", true},
+		{"default", false},
+	} {
+		t.Run(tt.mode, func(t *testing.T) {
+			r, w, err := os.Pipe()
+			if err != nil {
+				t.Fatal(err)
+			}
+			r.Close()
+			defer w.Close()
+			var stderr bytes.Buffer
+			cmd := exec.Command(os.Args[0], "-test.run=^TestIgnoreSIGPIPE$")
+			cmd.Env = append(os.Environ(), "GRIP_TEST_SIGPIPE="+tt.mode)
+			cmd.Stdout, cmd.Stderr = w, &stderr
+			err = cmd.Run()
+			survived := err == nil && strings.Contains(stderr.String(), "survived: true")
+			if survived != tt.survives {
+				t.Errorf("survived = %v, want %v (err %v, stderr %q)", survived, tt.survives, err, stderr.String())
+			}
+		})
+	}
+}
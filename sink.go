@@ -0,0 +1,134 @@
+package grip
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+)
+
+// A Sink receives the output of a shutdown, so Message, the Exit family and
+// Handler produce it in one format that can be plugged in. Message and Exit
+// write to an io.Writer through WriterSink; MessageTo, ExitTo and a Handler
+// configured WithSink accept any Sink.
+type Sink interface {
+	// Info reports a message about the received signal.
+	Info(s os.Signal, msg string)
+	// HandlerFailed reports that the ExitHandler at index failed with err,
+	// adding bit to the exit code. bit is 0 when the exit code is not a
+	// bitmask, as with ExitWith or WithEncoder.
+	HandlerFailed(index, bit int, err error)
+	// Summary reports the final exit code.
+	Summary(code int)
+	// Note reports any other line of output, such as a signal dropped because
+	// a shutdown is already in progress or an error ignored by
+	// WithIgnoredErrors.
+	Note(msg string)
+}
+
+// WriterSink returns a Sink writing lines of text to w, in the format of
+// Message and Exit:
+//
+//	received shutdown request: terminated
+//	added 2 to exit code for error: connection reset
+//	exit code 2
+//	ignored interrupt: shutdown already in progress
+//
+// A nil w discards the output.
+func WriterSink(w io.Writer) Sink {
+	return writerSink{orDiscard(w)}
+}
+
+type writerSink struct {
+	w io.Writer
+}
+
+func (s writerSink) Info(sig os.Signal, msg string) {
+	fmt.Fprintf(s.w, "%s: %s\n", msg, sig)
+}
+
+func (s writerSink) HandlerFailed(index, bit int, err error) {
+	var l *labeledError
+	switch {
+	case errors.As(err, &l) && bit == 0:
+		fmt.Fprintf(s.w, "handler '%s' failed: %s\n", l.name, l.err)
+	case errors.As(err, &l):
+		fmt.Fprintf(s.w, "handler '%s' failed (bit %d): %s\n", l.name, bit, l.err)
+	case bit == 0:
+		fmt.Fprintf(s.w, "exit handler %d failed: %s\n", index, err)
+	default:
+		fmt.Fprintf(s.w, "added %d to exit code for error: %s\n", bit, err)
+	}
+}
+
+func (s writerSink) Summary(code int) {
+	fmt.Fprintf(s.w, "exit code %d\n", code)
+}
+
+func (s writerSink) Note(msg string) {
+	fmt.Fprintln(s.w, msg)
+}
+
+// SlogSink returns a Sink logging to l: messages at info level with the
+// signal, failed ExitHandlers at error level with their index, bit and error,
+// the summary at info level with the exit code, and other notes at warn
+// level.
+func SlogSink(l *slog.Logger) Sink {
+	return slogSink{l}
+}
+
+type slogSink struct {
+	l *slog.Logger
+}
+
+func (s slogSink) Info(sig os.Signal, msg string) {
+	s.l.Info(msg, "signal", sig.String())
+}
+
+func (s slogSink) HandlerFailed(index, bit int, err error) {
+	args := []any{"index", index, "bit", bit, "error", err.Error()}
+	var l *labeledError
+	if errors.As(err, &l) {
+		args = append(args, "name", l.name)
+	}
+	s.l.Error("exit handler failed", args...)
+}
+
+func (s slogSink) Summary(code int) {
+	s.l.Info("shutdown complete", "code", code)
+}
+
+func (s slogSink) Note(msg string) {
+	s.l.Warn(msg)
+}
+
+// MessageTo is like Message but reports m and the signal to sink.
+func MessageTo(sink Sink, m string, fn SignalHandler) SignalHandler {
+	return func(s os.Signal) {
+		sink.Info(s, m)
+		fn(s)
+	}
+}
+
+// ExitTo is like Exit but reports failed ExitHandlers to sink, followed by
+// the exit code as a summary once they have all run:
+//
+//	sink := grip.SlogSink(slog.Default())
+//	grip.Trap(grip.MessageTo(sink, "shutting down", grip.ExitTo(ch, sink, stopHTTP, closeDB)),
+//		syscall.SIGINT, syscall.SIGTERM)
+func ExitTo(ch chan int, sink Sink, fn ...ExitHandler) SignalHandler {
+	return exclusive(sink, func(_ os.Signal) {
+		code := Bitmask(runExitHandlers(fn, sinkReporter(sink)))
+		sink.Summary(code)
+		ch <- code
+	})
+}
+
+// sinkReporter returns a function that reports a failed HandlerResult to sink
+// along with the bit it adds to the exit code.
+func sinkReporter(sink Sink) func(HandlerResult) {
+	return func(r HandlerResult) {
+		sink.HandlerFailed(r.Index, 1<<r.Index, r.Err)
+	}
+}
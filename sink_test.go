@@ -0,0 +1,127 @@
+package grip_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/codycraven/grip"
+)
+
+// recordSink records each call as a line of text.
+type recordSink struct {
+	lines []string
+}
+
+func (s *recordSink) Info(sig os.Signal, msg string) {
+	s.lines = append(s.lines, fmt.Sprintf("info %s %s", sig, msg))
+}
+
+func (s *recordSink) HandlerFailed(index, bit int, err error) {
+	s.lines = append(s.lines, fmt.Sprintf("failed %d %d %s", index, bit, err))
+}
+
+func (s *recordSink) Summary(code int) {
+	s.lines = append(s.lines, fmt.Sprintf("summary %d", code))
+}
+
+func (s *recordSink) Note(msg string) {
+	s.lines = append(s.lines, "note "+msg)
+}
+
+func TestWriterSink(t *testing.T) {
+	var buf bytes.Buffer
+	ch := make(chan int, 1)
+	sink := grip.WriterSink(&buf)
+	grip.MessageTo(sink, "received shutdown request", grip.ExitTo(ch, sink,
+		pass,
+		func() error { return errors.New("connection reset") },
+		grip.Label("db", fail),
+	))(syscall.SIGTERM)
+	if code := <-ch; code != 6 {
+		t.Errorf("exit code %d, want 6", code)
+	}
+	want := "received shutdown request: terminated\n" +
+		"added 2 to exit code for error: connection reset\n" +
+		"handler 'db' failed (bit 4): failed\n" +
+		"exit code 6\n"
+	if buf.String() != want {
+		t.Errorf("output %q, want %q", buf.String(), want)
+	}
+}
+
+func TestSlogSink(t *testing.T) {
+	var buf bytes.Buffer
+	l := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+	ch := make(chan int, 1)
+	sink := grip.SlogSink(l)
+	grip.MessageTo(sink, "shutting down", grip.ExitTo(ch, sink, grip.Label("db", fail)))(syscall.SIGINT)
+	<-ch
+	sink.Note("ignored terminated: shutdown already in progress")
+	want := []string{
+		`level=INFO msg="shutting down" signal=interrupt`,
+		`level=ERROR msg="exit handler failed" index=0 bit=1 error=failed name=db`,
+		`level=INFO msg="shutdown complete" code=1`,
+		`level=WARN msg="ignored terminated: shutdown already in progress"`,
+	}
+	if got := strings.Split(strings.TrimSpace(buf.String()), "\n"); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("logged\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestWithSink(t *testing.T) {
+	sink := &recordSink{}
+	var errOut bytes.Buffer
+	ch := make(chan int, 1)
+	h := grip.New(
+		grip.WithSignals(syscall.SIGTERM),
+		grip.WithErrorWriter(&errOut),
+		grip.WithSink(sink),
+		grip.WithExitHandlers(pass, fail),
+	)
+	trap := h.Trap(ch)
+	t.Cleanup(trap.Stop)
+	trap.Trigger(syscall.SIGTERM)
+	if code := <-ch; code != 2 {
+		t.Errorf("exit code %d, want 2", code)
+	}
+	want := []string{"failed 1 2 failed", "summary 2"}
+	if strings.Join(sink.lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("sink received %q, want %q", sink.lines, want)
+	}
+	if errOut.Len() != 0 {
+		t.Errorf("error writer received %q with a Sink set", errOut.String())
+	}
+}
+
+func TestExitToDropped(t *testing.T) {
+	sink := &recordSink{}
+	ch := make(chan int, 1)
+	started, release := make(chan struct{}), make(chan struct{})
+	h := grip.ExitTo(ch, sink, func() error {
+		close(started)
+		<-release
+		return nil
+	})
+	go h(syscall.SIGTERM)
+	<-started
+	h(syscall.SIGINT)
+	close(release)
+	<-ch
+	want := []string{"note ignored interrupt: shutdown already in progress", "summary 0"}
+	if strings.Join(sink.lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("sink received %q, want %q", sink.lines, want)
+	}
+}
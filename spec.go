@@ -0,0 +1,106 @@
+package grip
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// A Spec describes a complete shutdown setup as data, for applications that
+// assemble it from configuration rather than by calling functions directly.
+type Spec struct {
+	// Signals are the signals trapped by Spec.Trap.
+	Signals []os.Signal
+	// Message, if set, is written with the received signal as Message does.
+	Message string
+	// Handlers are the ExitHandlers to run.
+	Handlers []NamedExitHandler
+	// Timeout, if set, caps the time spent running Handlers: as ExitWithin
+	// when they run sequentially, or for each of them when Concurrent.
+	Timeout time.Duration
+	// Concurrent runs all Handlers at once, as ExitConcurrentN does.
+	Concurrent bool
+	// ExitCodes receives the exit code. It is required.
+	ExitCodes chan int
+	// Output receives Message and the errors of failed Handlers. If nil,
+	// nothing is written.
+	Output io.Writer
+}
+
+// Build validates spec and returns the SignalHandler it describes, equivalent
+// to composing Message, Exit, ExitWithin or ExitConcurrentN by hand:
+//
+//	fn, err := grip.Build(grip.Spec{
+//		Message:   "received shutdown request",
+//		Handlers:  []grip.NamedExitHandler{{Name: "http", Fn: stopHTTP}, {Name: "db", Fn: closeDB}},
+//		Timeout:   10 * time.Second,
+//		ExitCodes: ch,
+//		Output:    os.Stderr,
+//	})
+//
+// is the same as
+//
+//	fn := grip.Message("received shutdown request", os.Stderr,
+//		grip.ExitWithin(10*time.Second, ch, os.Stderr, stopHTTP, closeDB))
+func Build(spec Spec) (SignalHandler, error) {
+	if err := spec.validate(); err != nil {
+		return nil, err
+	}
+	fn := make([]ExitHandler, len(spec.Handlers))
+	for i, n := range spec.Handlers {
+		fn[i] = n.Fn
+	}
+	var h SignalHandler
+	switch {
+	case spec.Concurrent:
+		if spec.Timeout > 0 {
+			for i, f := range fn {
+				fn[i] = Timeout(spec.Timeout, f)
+			}
+		}
+		h = ExitConcurrentN(0, spec.ExitCodes, spec.Output, fn...)
+	case spec.Timeout > 0:
+		h = ExitWithin(spec.Timeout, spec.ExitCodes, spec.Output, fn...)
+	default:
+		h = Exit(spec.ExitCodes, spec.Output, fn...)
+	}
+	if spec.Message != "" {
+		h = Message(spec.Message, spec.Output, h)
+	}
+	return h, nil
+}
+
+// Trap builds spec and traps its Signals with the result.
+func (spec Spec) Trap() (*TrapHandle, error) {
+	if len(spec.Signals) == 0 {
+		return nil, errors.New("spec has no signals")
+	}
+	fn, err := Build(spec)
+	if err != nil {
+		return nil, err
+	}
+	return Trap(fn, spec.Signals...), nil
+}
+
+// validate reports the first problem preventing spec from being built.
+func (spec Spec) validate() error {
+	if spec.ExitCodes == nil {
+		return errors.New("spec has no exit code channel")
+	}
+	if spec.Timeout < 0 {
+		return fmt.Errorf("spec has negative timeout %s", spec.Timeout)
+	}
+	names := make(map[string]bool, len(spec.Handlers))
+	for i, n := range spec.Handlers {
+		if n.Fn == nil {
+			return fmt.Errorf("spec handler %s has no Fn", n.label(i))
+		}
+		if n.Name != "" && names[n.Name] {
+			return fmt.Errorf("spec has duplicate handler name %q", n.Name)
+		}
+		names[n.Name] = true
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package grip_test
+
+import (
+	"bytes"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/codycraven/grip"
+)
+
+func TestBuildEquivalent(t *testing.T) {
+	handlers := []grip.NamedExitHandler{{Name: "http", Fn: pass}, {Name: "db", Fn: fail}}
+	tests := []struct {
+		name       string
+		spec       grip.Spec
+		imperative func(ch chan int, w *bytes.Buffer) grip.SignalHandler
+	}{
+		{
+			"sequential with message",
+			grip.Spec{Message: "received shutdown request", Handlers: handlers},
+			func(ch chan int, w *bytes.Buffer) grip.SignalHandler {
+				return grip.Message("received shutdown request", w, grip.Exit(ch, w, pass, fail))
+			},
+		},
+		{
+			"timeout",
+			grip.Spec{Handlers: handlers, Timeout: time.Second},
+			func(ch chan int, w *bytes.Buffer) grip.SignalHandler {
+				return grip.ExitWithin(time.Second, ch, w, pass, fail)
+			},
+		},
+		{
+			"concurrent",
+			grip.Spec{Handlers: handlers, Concurrent: true},
+			func(ch chan int, w *bytes.Buffer) grip.SignalHandler {
+				return grip.ExitConcurrentN(0, ch, w, pass, fail)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var specOut, impOut bytes.Buffer
+			specCh, impCh := make(chan int, 1), make(chan int, 1)
+			tt.spec.ExitCodes, tt.spec.Output = specCh, &specOut
+			fn, err := grip.Build(tt.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fn(syscall.SIGTERM)
+			tt.imperative(impCh, &impOut)(syscall.SIGTERM)
+			if got, want := <-specCh, <-impCh; got != want {
+				t.Errorf("exit code %d, want %d", got, want)
+			}
+			if specOut.String() != impOut.String() {
+				t.Errorf("output %q, want %q", specOut.String(), impOut.String())
+			}
+		})
+	}
+}
+
+func TestBuildInvalid(t *testing.T) {
+	ch := make(chan int)
+	for name, spec := range map[string]grip.Spec{
+		"no channel":       {},
+		"negative timeout": {ExitCodes: ch, Timeout: -time.Second},
+		"missing Fn":       {ExitCodes: ch, Handlers: []grip.NamedExitHandler{{Name: "db"}}},
+		"duplicate name":   {ExitCodes: ch, Handlers: []grip.NamedExitHandler{{Name: "db", Fn: pass}, {Name: "db", Fn: pass}}},
+	} {
+		if _, err := grip.Build(spec); err == nil {
+			t.Errorf("%s: Build returned no error", name)
+		}
+	}
+	if _, err := (grip.Spec{ExitCodes: ch}).Trap(); err == nil {
+		t.Error("Trap without signals returned no error")
+	}
+}
@@ -0,0 +1,73 @@
+package grip
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// A Stack runs ExitHandlers in the reverse of the order they were added, like
+// deferred function calls. Adding each cleanup as its resource is acquired
+// keeps the shutdown order right by construction:
+//
+//	st := grip.NewStack()
+//	db := openDB()
+//	st.Defer("db", db.Close)
+//	srv := startHTTP(db)
+//	st.Defer("http", grip.Shutdownable(ctx, srv))
+//
+//	ch := make(chan int)
+//	grip.Trap(st.Exit(ch, os.Stderr), syscall.SIGINT, syscall.SIGTERM)
+//	os.Exit(<-ch)
+//
+// Here http is shut down before db.
+//
+// A Stack is safe for concurrent use.
+type Stack struct {
+	mu       sync.Mutex
+	handlers []NamedExitHandler
+}
+
+// NewStack creates an empty Stack.
+func NewStack() *Stack {
+	return &Stack{}
+}
+
+// Defer pushes an ExitHandler onto the Stack under name.
+func (st *Stack) Defer(name string, fn ExitHandler) {
+	st.mu.Lock()
+	st.handlers = append(st.handlers, NamedExitHandler{Name: name, Fn: fn})
+	st.mu.Unlock()
+}
+
+// Run calls the ExitHandlers last in, first out and returns the bitmask exit
+// code described by Exit.
+//
+// Bits follow push order rather than run order, so the first ExitHandler
+// deferred adds 1 when it fails even though it runs last. Failures are written
+// to errWriter prefixed with their name.
+func (st *Stack) Run(errWriter io.Writer) int {
+	st.mu.Lock()
+	handlers := st.handlers[:len(st.handlers):len(st.handlers)]
+	st.mu.Unlock()
+
+	shutting.Store(true)
+	report := bitReporter(errWriter)
+	results := make([]HandlerResult, len(handlers))
+	for i := len(handlers) - 1; i >= 0; i-- {
+		results[i] = newResult(i, handlers[i].Fn())
+		if results[i].Failed() {
+			results[i].Err = fmt.Errorf("%s: %w", handlers[i].label(i), results[i].Err)
+			report(results[i])
+		}
+	}
+	return Bitmask(results)
+}
+
+// Exit creates a SignalHandler that calls Run and passes the exit code to ch.
+func (st *Stack) Exit(ch chan int, errWriter io.Writer) SignalHandler {
+	return exclusive(WriterSink(errWriter), func(_ os.Signal) {
+		ch <- st.Run(errWriter)
+	})
+}
@@ -0,0 +1,38 @@
+package grip_test
+
+import (
+	"errors"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/codycraven/grip"
+)
+
+func TestStack(t *testing.T) {
+	var ran []string
+	push := func(st *grip.Stack, name string, err error) {
+		st.Defer(name, func() error {
+			ran = append(ran, name)
+			return err
+		})
+	}
+	st := grip.NewStack()
+	push(st, "db", errors.New("db"))
+	push(st, "cache", nil)
+	push(st, "http", errors.New("http"))
+
+	var buf strings.Builder
+	ch := make(chan int, 1)
+	st.Exit(ch, &buf)(syscall.SIGTERM)
+	if got := strings.Join(ran, " "); got != "http cache db" {
+		t.Errorf("ran %s, want http cache db", got)
+	}
+	// Bits follow push order: db is 1 and http is 4.
+	if got := <-ch; got != 5 {
+		t.Errorf("exit code %d, want 5", got)
+	}
+	if want := "added 4 to exit code for error: http: http\nadded 1 to exit code for error: db: db\n"; buf.String() != want {
+		t.Errorf("output %q, want %q", buf.String(), want)
+	}
+}
@@ -0,0 +1,14 @@
+//go:build unix
+
+package grip
+
+import (
+	"io"
+	"syscall"
+	"time"
+)
+
+// TrapStats writes Stats to w every time SIGUSR1 is received.
+func TrapStats(w io.Writer, start time.Time) *TrapHandle {
+	return TrapRepeat(Stats(w, start), syscall.SIGUSR1)
+}
@@ -0,0 +1,28 @@
+//go:build unix
+
+package grip_test
+
+import (
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/codycraven/grip"
+	"github.com/codycraven/grip/griptest"
+)
+
+func TestTrapStats(t *testing.T) {
+	var buf syncBuffer
+	h := grip.TrapStats(&buf, time.Now())
+	t.Cleanup(h.Stop)
+	griptest.WaitRegistered(t, syscall.SIGUSR1)
+	for i := 0; i < 2; i++ {
+		done := h.Done()
+		griptest.Send(syscall.SIGUSR1)
+		<-done
+	}
+	if n := strings.Count(buf.String(), "uptime="); n != 2 {
+		t.Errorf("wrote statistics %d times, want 2:\n%s", n, buf.String())
+	}
+}
@@ -0,0 +1,12 @@
+//go:build !unix
+
+package grip
+
+// SyncDir creates an ExitHandler that would fsync the directory at path.
+// Directories cannot be fsynced on this platform, so it does nothing and
+// returns nil.
+func SyncDir(path string) ExitHandler {
+	return func() error {
+		return nil
+	}
+}
@@ -0,0 +1,27 @@
+//go:build unix
+
+package grip
+
+import "os"
+
+// SyncDir creates an ExitHandler that fsyncs the directory at path, making
+// sure files created, renamed or removed in it survive a crash, as databases
+// and append-only stores need on shutdown.
+//
+//	grip.Exit(ch, os.Stderr, closeStore, grip.SyncDir("/var/lib/store"))
+//
+// Directories can only be fsynced on Unix. Elsewhere the ExitHandler does
+// nothing and returns nil.
+func SyncDir(path string) ExitHandler {
+	return func() error {
+		d, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		if err := d.Sync(); err != nil {
+			d.Close()
+			return err
+		}
+		return d.Close()
+	}
+}
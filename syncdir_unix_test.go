@@ -0,0 +1,27 @@
+//go:build unix
+
+package grip_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/codycraven/grip"
+)
+
+func TestSyncDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "wal"), []byte("entry"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := grip.SyncDir(dir)(); err != nil {
+		t.Errorf("SyncDir returned %v", err)
+	}
+}
+
+func TestSyncDirMissing(t *testing.T) {
+	if err := grip.SyncDir(filepath.Join(t.TempDir(), "missing"))(); !os.IsNotExist(err) {
+		t.Errorf("SyncDir returned %v, want a not-exist error", err)
+	}
+}
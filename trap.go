@@ -0,0 +1,391 @@
+package grip
+
+import (
+	"log/slog"
+	"os"
+	"sync"
+)
+
+// A TrapHandle controls the signal handling registered by Trap.
+type TrapHandle struct {
+	fn SignalHandler
+	// ch receives the signals to deliver, from the os/signal registration
+	// through forward and from Trigger.
+	ch     chan os.Signal
+	logger *slog.Logger
+	repeat bool
+	// sem bounds concurrent calls to fn when set; otherwise calls are serial.
+	sem chan struct{}
+
+	mu sync.Mutex
+	// notified is the channel registered with os/signal, if any, and quit
+	// stops the forward goroutine reading it.
+	notified chan os.Signal
+	quit     chan struct{}
+	signals  []os.Signal
+	// all is set while the TrapHandle traps every signal, as Trap does when
+	// given none.
+	all     bool
+	paused  bool
+	pending os.Signal
+	done    chan struct{}
+}
+
+// newTrapHandle creates a TrapHandle that delivers to fn.
+func newTrapHandle(fn SignalHandler, logger *slog.Logger, repeat bool) *TrapHandle {
+	return &TrapHandle{
+		fn:     fn,
+		ch:     make(chan os.Signal, 1),
+		logger: logger,
+		repeat: repeat,
+		done:   make(chan struct{}),
+	}
+}
+
+// Pause stops delivering signals to the SignalHandler until Resume is called.
+//
+// Signals received while paused are not lost: the most recent one is kept and
+// delivered on Resume. Multiple signals received while paused are coalesced
+// into that single delivery. Pause is useful to protect a critical section,
+// such as a migration, from being interrupted by shutdown.
+//
+//	t := grip.Trap(handler, syscall.SIGINT, syscall.SIGTERM)
+//	t.Pause()
+//	migrate()
+//	t.Resume()
+func (t *TrapHandle) Pause() {
+	t.mu.Lock()
+	t.paused = true
+	t.mu.Unlock()
+}
+
+// Resume restarts delivering signals to the SignalHandler after Pause. If a
+// signal was received while paused, the most recent one is delivered.
+func (t *TrapHandle) Resume() {
+	t.mu.Lock()
+	s := t.pending
+	t.paused, t.pending = false, nil
+	t.mu.Unlock()
+	if s != nil {
+		// A full channel already holds a signal received after s, which
+		// supersedes it.
+		t.Trigger(s)
+	}
+}
+
+// Done returns a channel that is closed once the SignalHandler has returned, so
+// main can wait for handling to finish without a channel of its own:
+//
+//	ch := make(chan int, 1)
+//	t := grip.Trap(grip.Exit(ch, os.Stderr, closeDB), syscall.SIGINT, syscall.SIGTERM)
+//	<-t.Done()
+//	os.Exit(<-ch)
+//
+// Note that ch is buffered above, since Exit does not return until its exit
+// code has been received.
+//
+// With TrapRepeat the channel is closed after each call to the SignalHandler.
+// Done then returns a new channel for the following call, so call Done again
+// to wait for the next completion.
+func (t *TrapHandle) Done() <-chan struct{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.done
+}
+
+// Stop stops trapping every signal of the TrapHandle. Signals that are not
+// trapped elsewhere revert to their default behavior. Trigger keeps working
+// after Stop.
+func (t *TrapHandle) Stop() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.debug("grip: signals unregistered", "signals", t.signals)
+	t.setSignals(nil)
+}
+
+// Remove stops trapping only the provided signals, leaving the TrapHandle's
+// other signals trapped. Removed signals that are not trapped elsewhere revert
+// to their default behavior.
+//
+//	t := grip.TrapRepeat(handler, syscall.SIGHUP, syscall.SIGTERM)
+//	loadConfig()
+//	t.Remove(syscall.SIGHUP) // SIGHUP terminates the process again
+//
+// os/signal cannot unregister individual signals from a channel, so Remove
+// registers the remaining signals on a new channel before stopping the
+// previous registration. They stay trapped throughout, and one arriving during
+// the change may be delivered twice. A removed signal is never reset for other
+// traps, as signal.Reset would do. For the same reason Remove has no effect on a
+// TrapHandle trapping every signal.
+func (t *TrapHandle) Remove(s ...os.Signal) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.all {
+		return
+	}
+	var kept, removed []os.Signal
+	for _, sig := range t.signals {
+		if containsSignal(s, sig) {
+			removed = append(removed, sig)
+		} else {
+			kept = append(kept, sig)
+		}
+	}
+	t.debug("grip: signals unregistered", "signals", removed)
+	t.setSignals(kept)
+}
+
+// setSignals replaces the signals the TrapHandle is registered for with s,
+// none if s is empty. t.mu must be held.
+func (t *TrapHandle) setSignals(s []os.Signal) {
+	t.setRegistration(registration{signals: s})
+}
+
+// setRegistration replaces the TrapHandle's registration with r. t.mu must be
+// held.
+func (t *TrapHandle) setRegistration(r registration) {
+	prev, quit := t.notified, t.quit
+	t.notified, t.quit = nil, nil
+	// Notify without signals registers every signal, which only r.all asks
+	// for.
+	if r.all || len(r.signals) > 0 {
+		t.notified, t.quit = make(chan os.Signal, 1), make(chan struct{})
+		osSignal.Notify(t.notified, r.signals...)
+		go t.forward(t.notified, t.quit)
+	}
+	// Stopping the previous registration only now leaves no gap in which a
+	// signal kept across the change would get its default behavior.
+	if prev != nil {
+		osSignal.Stop(prev)
+		close(quit)
+	}
+	register(t, r)
+	t.signals, t.all = r.signals, r.all
+}
+
+// forward delivers the signals received on c until quit is closed, then
+// delivers any signal still buffered in c.
+func (t *TrapHandle) forward(c <-chan os.Signal, quit <-chan struct{}) {
+	for {
+		select {
+		case s := <-c:
+			t.Trigger(s)
+		case <-quit:
+			select {
+			case s := <-c:
+				t.Trigger(s)
+			default:
+			}
+			return
+		}
+	}
+}
+
+// containsSignal reports whether s contains sig.
+func containsSignal(s []os.Signal, sig os.Signal) bool {
+	for _, v := range s {
+		if v == sig {
+			return true
+		}
+	}
+	return false
+}
+
+// Trigger delivers a synthetic signal through the same path as a received
+// os.Signal, so Pause and any wrapping SignalHandlers apply to it exactly as
+// they would to a real signal. It is useful for "shut down now" admin actions
+// and for tests.
+//
+// Like os/signal, Trigger does not block: the signal is dropped if the
+// TrapHandle already has one waiting to be delivered.
+func (t *TrapHandle) Trigger(s os.Signal) {
+	select {
+	case t.ch <- s:
+	default:
+	}
+}
+
+// TrapRepeat is like Trap but calls the SignalHandler for every signal
+// received rather than only the first one. It suits SignalHandlers that do not
+// end the process, such as reloading configuration on SIGHUP:
+//
+//	grip.TrapRepeat(func(_ os.Signal) { reload() }, syscall.SIGHUP)
+//
+// The SignalHandler is called serially: signals arriving while it runs are
+// coalesced into a single call once it returns. WithMaxConcurrentHandlers
+// allows a bounded number of concurrent calls instead.
+func TrapRepeat(fn SignalHandler, s ...os.Signal) *TrapHandle {
+	return trap(fn, nil, true, s)
+}
+
+// A TrapOption configures a TrapHandle created by TrapRepeatWith.
+type TrapOption func(*TrapHandle)
+
+// WithMaxConcurrentHandlers lets up to n calls to the SignalHandler run at
+// once, each in its own goroutine, instead of the default of one call at a
+// time. Once n calls are running, further signals are coalesced as they are
+// when calls are serial, so the number of goroutines never grows beyond n
+// however fast signals arrive. An n below 2 keeps calls serial.
+func WithMaxConcurrentHandlers(n int) TrapOption {
+	return func(t *TrapHandle) {
+		if n > 1 {
+			t.sem = make(chan struct{}, n)
+		}
+	}
+}
+
+// TrapRepeatWith is like TrapRepeat configured with TrapOptions:
+//
+//	grip.TrapRepeatWith(refresh, []os.Signal{syscall.SIGUSR1}, grip.WithMaxConcurrentHandlers(4))
+func TrapRepeatWith(fn SignalHandler, s []os.Signal, opts ...TrapOption) *TrapHandle {
+	return trap(fn, nil, true, s, opts...)
+}
+
+// trap registers for s and starts delivering them to fn, either once or for
+// every signal when repeat is set. Lifecycle events are logged to logger at
+// debug level unless it is nil.
+func trap(fn SignalHandler, logger *slog.Logger, repeat bool, s []os.Signal, opts ...TrapOption) *TrapHandle {
+	t := newTrapHandle(fn, logger, repeat)
+	for _, opt := range opts {
+		opt(t)
+	}
+	t.mu.Lock()
+	// Like signal.Notify, no signals means every signal.
+	t.setRegistration(registration{signals: s, all: len(s) == 0})
+	t.mu.Unlock()
+	t.debug("grip: signals registered", "signals", s)
+	go t.run()
+	return t
+}
+
+// run waits for a signal and delivers it to the SignalHandler.
+func (t *TrapHandle) run() {
+	t.debug("grip: trap goroutine started")
+	defer t.debug("grip: trap goroutine stopped")
+	for s := range t.ch {
+		t.debug("grip: signal received", "signal", s)
+		if t.repeat && t.sem != nil {
+			t.sem <- struct{}{}
+			go func(s os.Signal) {
+				defer func() { <-t.sem }()
+				if !t.deliver(s) {
+					t.debug("grip: signal held while paused", "signal", s)
+				}
+			}(s)
+			continue
+		}
+		if !t.deliver(s) {
+			t.debug("grip: signal held while paused", "signal", s)
+		} else if !t.repeat {
+			return
+		}
+	}
+}
+
+// debug logs a lifecycle event if the TrapHandle has a logger.
+func (t *TrapHandle) debug(msg string, args ...any) {
+	if t.logger != nil {
+		t.logger.Debug(msg, args...)
+	}
+}
+
+// deliver calls the SignalHandler with s, or holds s as pending when paused.
+// It reports whether the SignalHandler was called.
+func (t *TrapHandle) deliver(s os.Signal) bool {
+	t.mu.Lock()
+	if t.paused {
+		t.pending = s
+		t.mu.Unlock()
+		return false
+	}
+	t.mu.Unlock()
+	t.fn(s)
+	t.mu.Lock()
+	close(t.done)
+	if t.repeat {
+		t.done = make(chan struct{})
+	}
+	t.mu.Unlock()
+	return true
+}
+
+// TrapChan is like Trap but receives signals from src instead of registering
+// for os.Signals itself. Combined with Merge it lets OS signals and
+// synthetic signals sent by the program share one SignalHandler:
+//
+//	osCh := make(chan os.Signal, 1)
+//	signal.Notify(osCh, syscall.SIGINT, syscall.SIGTERM)
+//	admin := make(chan os.Signal, 1) // admin <- syscall.SIGTERM from an HTTP handler
+//	grip.TrapChan(handler, grip.Merge(osCh, admin))
+//
+// Like os/signal, signals from src are dropped rather than blocking while the
+// TrapHandle already has one waiting to be delivered.
+func TrapChan(fn SignalHandler, src <-chan os.Signal) *TrapHandle {
+	t := newTrapHandle(fn, nil, false)
+	go func() {
+		for s := range src {
+			t.Trigger(s)
+		}
+	}()
+	go t.run()
+	return t
+}
+
+// Merge fans in signals from several channels into one. The returned channel
+// is closed once every source has been closed.
+//
+// Signals from a single source are delivered in the order they were received
+// from it, but no ordering or fairness is guaranteed between sources: when
+// several sources have signals ready, whichever is forwarded first is
+// unspecified.
+func Merge(sources ...<-chan os.Signal) <-chan os.Signal {
+	out := make(chan os.Signal, len(sources))
+	var wg sync.WaitGroup
+	wg.Add(len(sources))
+	for _, src := range sources {
+		go func(src <-chan os.Signal) {
+			defer wg.Done()
+			for s := range src {
+				out <- s
+			}
+		}(src)
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out
+}
+
+// OnClose calls fn with sig once done is closed, so a component that signals
+// shutdown by closing a channel goes through the same SignalHandler as OS
+// signals:
+//
+//	stop := grip.OnClose(worker.Dead(), syscall.SIGTERM, shutdown)
+//	defer stop()
+//
+// fn is called at most once, in its own goroutine. Calling the returned stop
+// function before done is closed prevents the call; calling it again has no
+// effect.
+func OnClose(done <-chan struct{}, sig os.Signal, fn SignalHandler) (stop func()) {
+	quit := make(chan struct{})
+	go func() {
+		select {
+		case <-done:
+			// select picks at random when both are ready, so check quit
+			// again or a stop before done was closed could be missed.
+			select {
+			case <-quit:
+			default:
+				fn(sig)
+			}
+		case <-quit:
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(quit)
+		})
+	}
+}
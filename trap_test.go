@@ -0,0 +1,257 @@
+//go:build !js
+
+package grip_test
+
+import (
+	"os"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/codycraven/grip"
+	"github.com/codycraven/grip/griptest"
+)
+
+// recv returns a SignalHandler sending each signal it is called with to a
+// channel, and that channel.
+func recv() (grip.SignalHandler, chan os.Signal) {
+	ch := make(chan os.Signal, 16)
+	return func(s os.Signal) { ch <- s }, ch
+}
+
+// want fails t unless sig arrives on ch within a second.
+func want(t *testing.T, ch <-chan os.Signal, sig os.Signal) {
+	t.Helper()
+	select {
+	case s := <-ch:
+		if s != sig {
+			t.Fatalf("got %s, want %s", s, sig)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("%s was not delivered", sig)
+	}
+}
+
+// none fails t if anything arrives on ch within a short wait.
+func none(t *testing.T, ch <-chan os.Signal) {
+	t.Helper()
+	select {
+	case s := <-ch:
+		t.Fatalf("unexpected %s", s)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestTrapPauseResume(t *testing.T) {
+	fn, got := recv()
+	h := grip.TrapRepeat(fn, syscall.SIGHUP)
+	t.Cleanup(h.Stop)
+
+	h.Pause()
+	h.Trigger(syscall.SIGHUP)
+	none(t, got)
+	h.Trigger(syscall.SIGINT)
+	none(t, got)
+
+	h.Resume()
+	want(t, got, syscall.SIGINT)
+	none(t, got)
+
+	h.Trigger(syscall.SIGHUP)
+	want(t, got, syscall.SIGHUP)
+}
+
+func TestMerge(t *testing.T) {
+	a, b := make(chan os.Signal), make(chan os.Signal)
+	out := grip.Merge(a, b)
+	go func() {
+		a <- syscall.SIGINT
+		b <- syscall.SIGTERM
+		close(a)
+		close(b)
+	}()
+	got := map[os.Signal]bool{}
+	for s := range out {
+		got[s] = true
+	}
+	if len(got) != 2 || !got[syscall.SIGINT] || !got[syscall.SIGTERM] {
+		t.Errorf("merged %v, want SIGINT and SIGTERM", got)
+	}
+}
+
+func TestTrapChan(t *testing.T) {
+	fn, got := recv()
+	src := make(chan os.Signal, 1)
+	h := grip.TrapChan(fn, grip.Merge(src))
+	src <- syscall.SIGTERM
+	want(t, got, syscall.SIGTERM)
+	<-h.Done()
+}
+
+func TestTrapTrigger(t *testing.T) {
+	fn, got := recv()
+	h := grip.Trap(fn, syscall.SIGTERM)
+	t.Cleanup(h.Stop)
+	h.Trigger(syscall.SIGTERM)
+	want(t, got, syscall.SIGTERM)
+	<-h.Done()
+}
+
+func TestTrapTriggerWrapped(t *testing.T) {
+	fn, got := recv()
+	h := grip.TrapRepeat(grip.Once(fn), syscall.SIGHUP)
+	t.Cleanup(h.Stop)
+	h.Trigger(syscall.SIGHUP)
+	want(t, got, syscall.SIGHUP)
+	h.Trigger(syscall.SIGHUP)
+	none(t, got)
+}
+
+func TestTrapDone(t *testing.T) {
+	ch := make(chan int, 1)
+	h := grip.Trap(grip.Exit(ch, nil, fail), syscall.SIGTERM)
+	t.Cleanup(h.Stop)
+	done := h.Done()
+	select {
+	case <-done:
+		t.Fatal("Done closed before a signal")
+	default:
+	}
+	h.Trigger(syscall.SIGTERM)
+	<-done
+	select {
+	case code := <-ch:
+		if code != 1 {
+			t.Errorf("exit code %d, want 1", code)
+		}
+	default:
+		t.Error("Done closed before the exit code was sent")
+	}
+}
+
+func TestTrapRepeatDone(t *testing.T) {
+	fn, got := recv()
+	h := grip.TrapRepeat(fn, syscall.SIGHUP)
+	t.Cleanup(h.Stop)
+	first := h.Done()
+	h.Trigger(syscall.SIGHUP)
+	<-first
+	want(t, got, syscall.SIGHUP)
+	second := h.Done()
+	if second == first {
+		t.Fatal("Done returned the closed channel again")
+	}
+	h.Trigger(syscall.SIGHUP)
+	<-second
+	want(t, got, syscall.SIGHUP)
+}
+
+func TestTrapRemove(t *testing.T) {
+	fn, got := recv()
+	h := grip.TrapRepeat(fn, syscall.SIGHUP, syscall.SIGTERM)
+	t.Cleanup(h.Stop)
+	h.Remove(syscall.SIGHUP)
+	if griptest.Send(syscall.SIGHUP) {
+		t.Error("SIGHUP is still registered after Remove")
+	}
+	if !griptest.Send(syscall.SIGTERM) {
+		t.Fatal("SIGTERM is no longer registered after removing SIGHUP")
+	}
+	want(t, got, syscall.SIGTERM)
+}
+
+func TestTrapEverySignal(t *testing.T) {
+	fn, got := recv()
+	h := grip.Trap(fn)
+	t.Cleanup(h.Stop)
+	// Remove cannot narrow a registration for every signal.
+	h.Remove(syscall.SIGHUP)
+	if !griptest.Send(syscall.SIGHUP) {
+		t.Fatal("Trap without signals did not register every signal")
+	}
+	want(t, got, syscall.SIGHUP)
+}
+
+func TestTrapStop(t *testing.T) {
+	fn, _ := recv()
+	h := grip.TrapRepeat(fn, syscall.SIGHUP)
+	h.Stop()
+	if griptest.Send(syscall.SIGHUP) {
+		t.Error("SIGHUP is still registered after Stop")
+	}
+}
+
+func TestOnClose(t *testing.T) {
+	fn, got := recv()
+	done := make(chan struct{})
+	stop := grip.OnClose(done, syscall.SIGTERM, fn)
+	defer stop()
+	close(done)
+	want(t, got, syscall.SIGTERM)
+	none(t, got)
+}
+
+func TestOnCloseStop(t *testing.T) {
+	fn, got := recv()
+	done := make(chan struct{})
+	stop := grip.OnClose(done, syscall.SIGTERM, fn)
+	stop()
+	stop()
+	close(done)
+	none(t, got)
+}
+
+// concurrency returns a SignalHandler blocking until release is closed and a
+// function reporting how many calls are running and the most that ever were.
+func concurrency(release <-chan struct{}) (grip.SignalHandler, func() (running, peak int32)) {
+	var active, max atomic.Int32
+	fn := func(os.Signal) {
+		n := active.Add(1)
+		for {
+			m := max.Load()
+			if n <= m || max.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		<-release
+		active.Add(-1)
+	}
+	return fn, func() (int32, int32) { return active.Load(), max.Load() }
+}
+
+func TestWithMaxConcurrentHandlers(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		opts []grip.TrapOption
+		want int32
+	}{
+		{"serial by default", nil, 1},
+		{"below two is serial", []grip.TrapOption{grip.WithMaxConcurrentHandlers(1)}, 1},
+		{"bounded", []grip.TrapOption{grip.WithMaxConcurrentHandlers(3)}, 3},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			release := make(chan struct{})
+			fn, stats := concurrency(release)
+			h := grip.TrapRepeatWith(fn, []os.Signal{syscall.SIGHUP}, tt.opts...)
+			t.Cleanup(h.Stop)
+			defer close(release)
+
+			deadline := time.Now().Add(time.Second)
+			for running, _ := stats(); running < tt.want; running, _ = stats() {
+				if time.Now().After(deadline) {
+					t.Fatalf("%d calls running, want %d", running, tt.want)
+				}
+				h.Trigger(syscall.SIGHUP)
+				time.Sleep(time.Millisecond)
+			}
+			for i := 0; i < 50; i++ {
+				h.Trigger(syscall.SIGHUP)
+			}
+			time.Sleep(20 * time.Millisecond)
+			if _, peak := stats(); peak != tt.want {
+				t.Errorf("%d calls ran at once, want at most %d", peak, tt.want)
+			}
+		})
+	}
+}
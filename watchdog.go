@@ -0,0 +1,116 @@
+package grip
+
+import (
+	"sync"
+	"time"
+)
+
+// Watchdog wraps ExitHandlers so the process is forced to exit, by calling
+// ExitFunc with code, if none of them starts or finishes for idle. It catches
+// an ExitHandler that hangs without having to guess a timeout for each one.
+//
+// It returns the wrapped ExitHandlers and a channel to pass to the Exit family
+// in place of ch. The watchdog starts with the first ExitHandler of a sequence
+// and stops when the sequence sends its exit code, which is then forwarded to
+// ch:
+//
+//	handlers, codes := grip.Watchdog(30*time.Second, 124, ch, stopHTTP, drainQueue, closeDB)
+//	grip.Trap(grip.ExitWithin(time.Minute, codes, os.Stderr, handlers...), syscall.SIGTERM)
+//
+// Stopping with the exit code rather than with the last ExitHandler means a
+// sequence that ends early, as ExitWithin does once its time is up, does not
+// leave the watchdog running. Each sequence starts it afresh, so it works with
+// a repeating Trap. Idle time is measured on DefaultClock.
+func Watchdog(idle time.Duration, code int, ch chan int, fn ...ExitHandler) ([]ExitHandler, chan int) {
+	w := &watchdog{idle: idle, code: code}
+	codes := make(chan int)
+	go func() {
+		for c := range codes {
+			w.stop()
+			ch <- c
+		}
+	}()
+	handlers := make([]ExitHandler, len(fn))
+	for i, f := range fn {
+		f := f
+		handlers[i] = func() error {
+			run := w.start()
+			err := f()
+			w.touch(run)
+			return err
+		}
+	}
+	return handlers, codes
+}
+
+// watchdog tracks the progress of the ExitHandlers wrapped by Watchdog.
+type watchdog struct {
+	idle time.Duration
+	code int
+
+	mu    sync.Mutex
+	last  time.Time
+	timer Timer
+	// run counts the sequences the watchdog has been started for, so an
+	// ExitHandler returning after its sequence ended is not taken as progress
+	// of the next one.
+	run int
+}
+
+// start records the start of an ExitHandler, starting the watchdog if it is
+// not running, and returns the sequence it belongs to.
+func (w *watchdog) start() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.last = DefaultClock.Now()
+	if w.timer == nil {
+		w.run++
+		w.arm(w.run, w.idle)
+	}
+	return w.run
+}
+
+// touch records the return of an ExitHandler of sequence run.
+func (w *watchdog) touch(run int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timer != nil && w.run == run {
+		w.last = DefaultClock.Now()
+	}
+}
+
+// arm schedules a check of sequence run after d. w.mu must be held.
+func (w *watchdog) arm(run int, d time.Duration) {
+	w.timer = DefaultClock.AfterFunc(d, func() {
+		w.check(run)
+	})
+}
+
+// stop stops the watchdog until the next sequence starts it.
+func (w *watchdog) stop() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+}
+
+// check exits if sequence run has made no progress for idle, and otherwise
+// checks again once idle will have passed since the last progress.
+func (w *watchdog) check(run int) {
+	w.mu.Lock()
+	if w.timer == nil || w.run != run {
+		w.mu.Unlock()
+		return
+	}
+	since := DefaultClock.Now().Sub(w.last)
+	if since < w.idle {
+		w.arm(run, w.idle-since)
+		w.mu.Unlock()
+		return
+	}
+	w.timer = nil
+	w.mu.Unlock()
+	ExitFunc(w.code)
+}
@@ -0,0 +1,94 @@
+package grip_test
+
+import (
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/codycraven/grip"
+	"github.com/codycraven/grip/griptest"
+)
+
+// waitPending waits for clock to have n pending timers.
+func waitPending(t *testing.T, clock *griptest.FakeClock, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for clock.Pending() != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d timers pending, want %d", clock.Pending(), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestWatchdog(t *testing.T) {
+	clock := griptest.NewFakeClock(t)
+	exits := griptest.CaptureExit(t)
+	release := make(chan struct{})
+	ch := make(chan int, 1)
+	handlers, codes := grip.Watchdog(30*time.Second, 124, ch, pass, func() error {
+		<-release
+		return nil
+	})
+	go grip.Exit(codes, nil, handlers...)(syscall.SIGTERM)
+	waitPending(t, clock, 1)
+
+	clock.Advance(29 * time.Second)
+	select {
+	case code := <-exits:
+		t.Fatalf("forced exit with %d before the idle interval", code)
+	default:
+	}
+	clock.Advance(time.Second)
+	select {
+	case code := <-exits:
+		if code != 124 {
+			t.Errorf("exit code %d, want 124", code)
+		}
+	default:
+		t.Fatal("no forced exit once the ExitHandler stalled for the idle interval")
+	}
+	close(release)
+	<-ch
+}
+
+func TestWatchdogStopsWithSequence(t *testing.T) {
+	clock := griptest.NewFakeClock(t)
+	exits := griptest.CaptureExit(t)
+	release := make(chan struct{})
+	defer close(release)
+	ch := make(chan int, 1)
+	handlers, codes := grip.Watchdog(30*time.Second, 124, ch, func() error {
+		<-release
+		return nil
+	})
+	h := grip.ExitWithin(100*time.Millisecond, codes, nil, handlers...)
+
+	// ExitWithin gives up on the stalled ExitHandler and sends its code,
+	// which stops the watchdog although the ExitHandler is still running.
+	h(syscall.SIGTERM)
+	if code := <-ch; code != 1 {
+		t.Errorf("exit code %d, want 1", code)
+	}
+	clock.Advance(time.Minute)
+	select {
+	case code := <-exits:
+		t.Fatalf("forced exit with %d after the sequence ended", code)
+	default:
+	}
+
+	// The next sequence starts the watchdog afresh.
+	release <- struct{}{}
+	go h(syscall.SIGTERM)
+	waitPending(t, clock, 1)
+	clock.Advance(30 * time.Second)
+	select {
+	case code := <-exits:
+		if code != 124 {
+			t.Errorf("exit code %d, want 124", code)
+		}
+	default:
+		t.Fatal("watchdog was not re-armed for the next sequence")
+	}
+	<-ch
+}